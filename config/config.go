@@ -0,0 +1,348 @@
+// Package config loads configuration structs such as the ones of tdengine/config and web.CorsConfig.
+//
+// The values are layered, each layer overriding the previous ones:
+//
+//  1. the default struct tags, for the fields still zero
+//  2. the files, in order, decoded by extension: .json, .yaml, .yml or .toml
+//  3. the environment variables PREFIX_FIELD_PATH, such as TDENGINE_RETRY_MAX_ATTEMPTS
+//  4. the overrides, keyed by dotted field paths such as retry.max_attempts
+//
+// Every problem is collected instead of stopping at the first one, then the structs implementing Validator are checked.
+//
+// Keys are the field names in snake case, the tags change them:
+//
+//	config:"name"   the key of the field, config:"-" skips it
+//	env:"NAME"      the environment variable segment of the field, env:"NAME,OLD" also reads the deprecated OLD when NAME is unset
+//	default:"10s"   the default value, slices are comma separated
+//	secret:"true"   masks the value in Dump, fields ending with password, token or secret are masked too
+//
+// Supported fields are strings, bools, numbers, time.Duration, slices of them and nested structs.
+// Other fields, such as interfaces, are left untouched.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/taosdata/go-utils/json"
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	// Files are read in order, a later file overrides an earlier one.
+	Files []string
+	// Section is the key of the config inside the files, such as tdengine, empty uses the whole file.
+	Section string
+	// EnvPrefix is the prefix of the environment variables, such as TDENGINE, empty skips the environment.
+	EnvPrefix string
+	// Overrides are applied last, such as the values of command line flags.
+	Overrides map[string]string
+	// KeepSet leaves the fields already set before Load untouched, as the Init methods of the configs do.
+	KeepSet bool
+}
+
+// Validator is implemented by the structs checking their values once loaded.
+// An error with an Unwrap() []error method, such as Errors, is reported as several errors.
+type Validator interface {
+	Validate() error
+}
+
+// Load fills target, a pointer to a struct, from the layers of options.
+// The returned error is Errors when anything is wrong.
+func Load(target interface{}, options *Options) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config target must be a non nil struct pointer, got %T", target)
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	l := &loader{leaves: map[string]*field{}, structs: map[string]bool{}, keepSet: options.KeepSet}
+	l.walk(v.Elem(), "", "", false)
+	l.applyDefaults()
+	for _, file := range options.Files {
+		l.applyFile(file, options.Section)
+	}
+	if options.EnvPrefix != "" {
+		l.applyEnv(options.EnvPrefix)
+	}
+	l.applyOverrides(options.Overrides)
+	if len(l.errs) == 0 {
+		l.validate(v, "")
+	}
+	if len(l.errs) != 0 {
+		return l.errs
+	}
+	return nil
+}
+
+// MustLoad is Load panicking on error, for configs that cannot be wrong at startup.
+func MustLoad(target interface{}, options *Options) {
+	if err := Load(target, options); err != nil {
+		panic(err)
+	}
+}
+
+type loader struct {
+	fields  []*field
+	leaves  map[string]*field
+	structs map[string]bool
+	keepSet bool
+	errs    Errors
+}
+
+// field is a loadable field of the target.
+type field struct {
+	key    string
+	env    string
+	secret bool
+	def    string
+	hasDef bool
+	// set 表示 Load 之前已有非零值
+	set   bool
+	value reflect.Value
+	// envAliases 为已废弃的环境变量名，env 未设置时依次读取
+	envAliases []string
+}
+
+func (l *loader) walk(v reflect.Value, key, env string, secret bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Tag.Get("config")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snakeCase(sf.Name)
+		}
+		envNames := strings.Split(sf.Tag.Get("env"), ",")
+		envName := envNames[0]
+		if envName == "" {
+			envName = strings.ToUpper(name)
+		}
+		fieldSecret := secret || sf.Tag.Get("secret") == "true" || isSecretName(name)
+		fv := v.Field(i)
+		if sf.Anonymous && fv.Kind() == reflect.Struct {
+			l.walk(fv, key, env, fieldSecret)
+			continue
+		}
+		fieldKey, fieldEnv := join(key, name, "."), join(env, envName, "_")
+		switch {
+		case fv.Kind() == reflect.Struct:
+			l.structs[fieldKey] = true
+			l.walk(fv, fieldKey, fieldEnv, fieldSecret)
+		case isSupported(fv.Type()):
+			def, hasDef := sf.Tag.Lookup("default")
+			f := &field{key: fieldKey, env: fieldEnv, secret: fieldSecret, def: def, hasDef: hasDef, set: !fv.IsZero(), value: fv}
+			for _, alias := range envNames[1:] {
+				f.envAliases = append(f.envAliases, join(env, alias, "_"))
+			}
+			l.fields = append(l.fields, f)
+			l.leaves[fieldKey] = f
+		}
+	}
+}
+
+// kept reports whether f keeps the value it had before Load.
+func (l *loader) kept(f *field) bool {
+	return l.keepSet && f.set
+}
+
+func (l *loader) fail(key, source string, err error) {
+	l.errs = append(l.errs, &FieldError{Key: key, Source: source, Err: err})
+}
+
+func (l *loader) applyDefaults() {
+	for _, f := range l.fields {
+		if f.hasDef && f.value.IsZero() {
+			if err := setString(f.value, f.def); err != nil {
+				l.fail(f.key, "default", err)
+			}
+		}
+	}
+}
+
+func (l *loader) applyFile(file, section string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		l.errs = append(l.errs, err)
+		return
+	}
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".json":
+		err = json.UnmarshalUseNumber(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		err = fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %w", file, err))
+		return
+	}
+	if section != "" {
+		for _, key := range strings.Split(section, ".") {
+			sub, ok := values[key].(map[string]interface{})
+			if !ok {
+				l.fail(section, file, errMissingSection)
+				return
+			}
+			values = sub
+		}
+	}
+	l.applyMap(file, "", values)
+}
+
+func (l *loader) applyMap(file, prefix string, values map[string]interface{}) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		val := values[name]
+		key := join(prefix, strings.ToLower(name), ".")
+		if f, ok := l.leaves[key]; ok {
+			if l.kept(f) {
+				continue
+			}
+			if err := setInterface(f.value, val); err != nil {
+				l.fail(key, file, err)
+			}
+			continue
+		}
+		if sub, ok := val.(map[string]interface{}); ok && l.structs[key] {
+			l.applyMap(file, key, sub)
+			continue
+		}
+		l.fail(key, file, errUnknownKey)
+	}
+}
+
+func (l *loader) applyEnv(prefix string) {
+	for _, f := range l.fields {
+		if l.kept(f) {
+			continue
+		}
+		for _, env := range append([]string{f.env}, f.envAliases...) {
+			name := prefix + "_" + env
+			if val, ok := os.LookupEnv(name); ok && val != "" {
+				if err := setString(f.value, val); err != nil {
+					l.fail(f.key, name, err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func (l *loader) applyOverrides(overrides map[string]string) {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := overrides[key]
+		f, ok := l.leaves[key]
+		if !ok {
+			l.fail(key, "override", errUnknownKey)
+			continue
+		}
+		if l.kept(f) {
+			continue
+		}
+		if err := setString(f.value, val); err != nil {
+			l.fail(key, "override", err)
+		}
+	}
+}
+
+// validate calls Validate on the nested structs first, then on v.
+func (l *loader) validate(v reflect.Value, key string) {
+	s := v
+	if s.Kind() == reflect.Ptr {
+		s = s.Elem()
+	}
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("config") == "-" || s.Field(i).Kind() != reflect.Struct {
+			continue
+		}
+		fieldKey := key
+		if !sf.Anonymous {
+			name := sf.Tag.Get("config")
+			if name == "" {
+				name = snakeCase(sf.Name)
+			}
+			fieldKey = join(key, name, ".")
+		}
+		l.validate(s.Field(i).Addr(), fieldKey)
+	}
+	validator, ok := v.Interface().(Validator)
+	if !ok {
+		return
+	}
+	err := validator.Validate()
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = multi.Unwrap()
+	}
+	for _, err := range errs {
+		l.fail(key, "validate", err)
+	}
+}
+
+func join(prefix, name, sep string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + sep + name
+}
+
+func isSecretName(name string) bool {
+	for _, secret := range []string{"password", "token", "secret"} {
+		if strings.HasSuffix(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase converts a field name, an upper case run is one word: CAFile is ca_file and KeyQPS key_qps.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || nextLower && runes[i-1] >= 'A' && runes[i-1] <= 'Z' {
+				b.WriteByte('_')
+			}
+		}
+		if upper {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testRetry struct {
+	MaxAttempts int           `default:"3"`
+	Backoff     time.Duration `default:"1s"`
+}
+
+type testConfig struct {
+	Address  string `default:"localhost"`
+	Port     int    `default:"6041"`
+	Tags     []string
+	Password string
+	Token    string `env:"AUTH_TOKEN"`
+	Key      string `secret:"true"`
+	Retry    testRetry
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := `{"address": "file", "port": 1, "retry": {"max_attempts": 5}}`
+	tests := []struct {
+		name      string
+		file      string
+		env       map[string]string
+		overrides map[string]string
+		want      testConfig
+	}{
+		{
+			name: "default",
+			want: testConfig{Address: "localhost", Port: 6041, Retry: testRetry{MaxAttempts: 3, Backoff: time.Second}},
+		},
+		{
+			name: "file",
+			file: file,
+			want: testConfig{Address: "file", Port: 1, Retry: testRetry{MaxAttempts: 5, Backoff: time.Second}},
+		},
+		{
+			name: "env",
+			file: file,
+			env:  map[string]string{"TEST_ADDRESS": "env", "TEST_RETRY_MAX_ATTEMPTS": "7", "TEST_AUTH_TOKEN": "t", "TEST_TAGS": "a,b"},
+			want: testConfig{Address: "env", Port: 1, Tags: []string{"a", "b"}, Token: "t", Retry: testRetry{MaxAttempts: 7, Backoff: time.Second}},
+		},
+		{
+			name:      "override",
+			file:      file,
+			env:       map[string]string{"TEST_ADDRESS": "env", "TEST_RETRY_MAX_ATTEMPTS": "7"},
+			overrides: map[string]string{"address": "override", "retry.backoff": "2s"},
+			want:      testConfig{Address: "override", Port: 1, Retry: testRetry{MaxAttempts: 7, Backoff: 2 * time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &Options{EnvPrefix: "TEST", Overrides: tt.overrides}
+			if tt.file != "" {
+				options.Files = []string{writeFile(t, "config.json", tt.file)}
+			}
+			for key, val := range tt.env {
+				t.Setenv(key, val)
+			}
+			var conf testConfig
+			if err := Load(&conf, options); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(conf, tt.want) {
+				t.Fatalf("got %+v, want %+v", conf, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKeepSet(t *testing.T) {
+	t.Setenv("TEST_ADDRESS", "env")
+	t.Setenv("TEST_PORT", "1")
+	conf := testConfig{Address: "set"}
+	if err := Load(&conf, &Options{EnvPrefix: "TEST", Overrides: map[string]string{"address": "override"}, KeepSet: true}); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Address != "set" || conf.Port != 1 || conf.Retry.MaxAttempts != 3 {
+		t.Fatalf("got %+v, want the set address kept and the other fields loaded", conf)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := writeFile(t, "config.json", `{"port": "x", "unknown": 1, "retry": {"max_attempts": "y", "other": 1}}`)
+	tests := []struct {
+		name    string
+		options *Options
+		env     map[string]string
+		want    []FieldError
+	}{
+		{
+			name:    "file",
+			options: &Options{Files: []string{file}},
+			want: []FieldError{
+				{Key: "port", Source: file},
+				{Key: "retry.max_attempts", Source: file},
+				{Key: "retry.other", Source: file, Err: errUnknownKey},
+				{Key: "unknown", Source: file, Err: errUnknownKey},
+			},
+		},
+		{
+			name:    "env and overrides",
+			options: &Options{EnvPrefix: "TEST", Overrides: map[string]string{"port": "z", "retry.nope": "1"}},
+			env:     map[string]string{"TEST_RETRY_BACKOFF": "soon"},
+			want: []FieldError{
+				{Key: "retry.backoff", Source: "TEST_RETRY_BACKOFF"},
+				{Key: "port", Source: "override"},
+				{Key: "retry.nope", Source: "override", Err: errUnknownKey},
+			},
+		},
+		{
+			name:    "missing section",
+			options: &Options{Files: []string{file}, Section: "tdengine"},
+			want:    []FieldError{{Key: "tdengine", Source: file, Err: errMissingSection}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, val := range tt.env {
+				t.Setenv(key, val)
+			}
+			err := Load(&testConfig{}, tt.options)
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) != len(tt.want) {
+				t.Fatalf("got %v, want %d errors", err, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got, ok := errs[i].(*FieldError)
+				if !ok || got.Key != want.Key || got.Source != want.Source {
+					t.Fatalf("error %d is %v, want %s from %s", i, errs[i], want.Key, want.Source)
+				}
+				if want.Err != nil && got.Err != want.Err {
+					t.Fatalf("error %d is %v, want %v", i, got.Err, want.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorsIsAs(t *testing.T) {
+	errs := Errors{errors.New("other"), &FieldError{Key: "port", Source: "override", Err: errUnknownKey}}
+	if !errs.Is(errUnknownKey) {
+		t.Fatal("Is does not find the unknown key error")
+	}
+	var fieldErr *FieldError
+	if !errs.As(&fieldErr) || fieldErr.Key != "port" {
+		t.Fatalf("As got %v, want the port error", fieldErr)
+	}
+}
+
+func TestDump(t *testing.T) {
+	conf := &testConfig{Address: "localhost", Port: 6041, Tags: []string{"a", "b"}, Password: "p", Token: "t", Retry: testRetry{MaxAttempts: 3, Backoff: time.Second}}
+	want := "address = localhost\n" +
+		"port = 6041\n" +
+		"tags = a,b\n" +
+		"password = ******\n" +
+		"token = ******\n" +
+		"key = \n" +
+		"retry.max_attempts = 3\n" +
+		"retry.backoff = 1s\n"
+	if got := Dump(conf); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadEnvAliases(t *testing.T) {
+	type aliasConfig struct {
+		Address string `env:"ADDRESS,ADDR,HOST"`
+	}
+	t.Setenv("TEST_HOST", "host")
+	t.Setenv("TEST_ADDR", "addr")
+	var conf aliasConfig
+	if err := Load(&conf, &Options{EnvPrefix: "TEST"}); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Address != "addr" {
+		t.Fatalf("got %s, want the first deprecated name set", conf.Address)
+	}
+	t.Setenv("TEST_ADDRESS", "address")
+	conf = aliasConfig{}
+	if err := Load(&conf, &Options{EnvPrefix: "TEST"}); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Address != "address" {
+		t.Fatalf("got %s, want the current name over the deprecated ones", conf.Address)
+	}
+}
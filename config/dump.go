@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const masked = "******"
+
+// Dump returns the effective config as key = value lines, in field order, for startup logs.
+// Secret values are masked, an empty secret stays empty to show it is not set.
+func Dump(target interface{}) string {
+	v := reflect.ValueOf(target)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprint(target)
+	}
+	l := &loader{leaves: map[string]*field{}, structs: map[string]bool{}}
+	l.walk(v, "", "", false)
+	var b strings.Builder
+	for _, f := range l.fields {
+		value := format(f.value)
+		if f.secret && value != "" {
+			value = masked
+		}
+		fmt.Fprintf(&b, "%s = %s\n", f.key, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errUnknownKey     = errors.New("unknown key")
+	errMissingSection = errors.New("missing section")
+)
+
+// FieldError is a value that could not be loaded or did not validate.
+// Source is default, the file name, the environment variable, override or validate.
+type FieldError struct {
+	Key    string
+	Source string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s (%s)", e.Err, e.Source)
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Key, e.Err, e.Source)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is every problem found by Load.
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return "invalid config: " + e[0].Error()
+	}
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d config errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Unwrap is used by errors.Is and errors.As from Go 1.20 on,
+// Is and As do the same for older versions when called on Errors directly.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Is reports whether any of the errors matches target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target and sets target to it.
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func isSupported(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setString sets v from text, slices are comma separated.
+func setString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Slice {
+		var items []interface{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return setSlice(v, items)
+	}
+	return setScalar(v, s)
+}
+
+// setInterface sets v from a value decoded from a file.
+func setInterface(v reflect.Value, val interface{}) error {
+	switch val := val.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	case map[string]interface{}:
+		return fmt.Errorf("expected a value, got a table")
+	case []interface{}:
+		if v.Kind() != reflect.Slice {
+			return fmt.Errorf("expected a value, got a list")
+		}
+		return setSlice(v, val)
+	case string:
+		return setString(v, val)
+	}
+	if v.Kind() == reflect.Slice {
+		return setSlice(v, []interface{}{val})
+	}
+	return setScalar(v, fmt.Sprint(val))
+}
+
+func setSlice(v reflect.Value, items []interface{}) error {
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if _, ok := item.([]interface{}); ok {
+			return fmt.Errorf("expected a list of values, got nested lists")
+		}
+		if _, ok := item.(map[string]interface{}); ok {
+			return fmt.Errorf("expected a list of values, got a table")
+		}
+		if err := setScalar(slice.Index(i), fmt.Sprint(item)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// format returns v as text, the way setString reads it.
+func format(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -3,6 +3,7 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"strconv"
@@ -14,8 +15,27 @@ var (
 	MarshalIndent = json.MarshalIndent
 	NewDecoder    = json.NewDecoder
 	NewEncoder    = json.NewEncoder
+	// NewTokenDecoder returns a decoder supporting Token for streaming reads.
+	NewTokenDecoder = json.NewDecoder
 )
 
+// UnmarshalUseNumber is Unmarshal decoding numbers in interface{} values as Number instead of float64.
+func UnmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	ConvertNumbers(v)
+	return nil
+}
+
+// Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim = json.Delim
+
+// TokenDecoder is the decoder returned by NewTokenDecoder.
+type TokenDecoder = json.Decoder
+
 // A Number represents a JSON number literal.
 type Number string
 
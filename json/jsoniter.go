@@ -3,6 +3,10 @@
 package json
 
 import (
+	stdjson "encoding/json"
+	"errors"
+	"strconv"
+
 	jsoniter "github.com/json-iterator/go"
 )
 
@@ -13,8 +17,32 @@ var (
 	MarshalIndent = _json.MarshalIndent
 	NewDecoder    = _json.NewDecoder
 	NewEncoder    = _json.NewEncoder
+	// NewTokenDecoder returns a decoder supporting Token for streaming reads,
+	// jsoniter's decoder has no Token so the standard library is used.
+	NewTokenDecoder = stdjson.NewDecoder
+	_jsonUseNumber  = jsoniter.Config{
+		EscapeHTML:             true,
+		SortMapKeys:            true,
+		ValidateJsonRawMessage: true,
+		UseNumber:              true,
+	}.Froze()
 )
 
+// UnmarshalUseNumber is Unmarshal decoding numbers in interface{} values as Number instead of float64.
+func UnmarshalUseNumber(data []byte, v interface{}) error {
+	if err := _jsonUseNumber.Unmarshal(data, v); err != nil {
+		return err
+	}
+	ConvertNumbers(v)
+	return nil
+}
+
+// Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim = stdjson.Delim
+
+// TokenDecoder is the decoder returned by NewTokenDecoder.
+type TokenDecoder = stdjson.Decoder
+
 type Number string
 
 // String returns the literal text of the number.
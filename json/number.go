@@ -0,0 +1,65 @@
+package json
+
+import (
+	stdjson "encoding/json"
+	"reflect"
+)
+
+var stdNumberType = reflect.TypeOf(stdjson.Number(""))
+
+// ConvertNumbers replaces, in the interface{} values reachable from v, the encoding/json Number put by decoders
+// using UseNumber with this package's Number, so callers match a single type whatever the build.
+// v is usually a pointer to what was decoded, numbers in fields typed as encoding/json Number are left as is.
+func ConvertNumbers(v interface{}) {
+	convertNumbers(reflect.ValueOf(v))
+}
+
+func convertNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			convertNumbers(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if n, ok := number(v.Elem()); ok {
+			if v.CanSet() {
+				v.Set(n)
+			}
+			return
+		}
+		convertNumbers(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			convertNumbers(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if value.Kind() == reflect.Interface && !value.IsNil() {
+				if n, ok := number(value.Elem()); ok {
+					v.SetMapIndex(iter.Key(), n)
+					continue
+				}
+			}
+			convertNumbers(value)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				convertNumbers(v.Field(i))
+			}
+		}
+	}
+}
+
+func number(v reflect.Value) (reflect.Value, bool) {
+	if v.Type() != stdNumberType {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(Number(v.String())), true
+}
@@ -0,0 +1,35 @@
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	var v map[string]interface{}
+	if err := UnmarshalUseNumber([]byte(`{"a":18446744073709551615,"b":[1.5,{"c":2}],"d":"e"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"a": Number("18446744073709551615"),
+		"b": []interface{}{Number("1.5"), map[string]interface{}{"c": Number("2")}},
+		"d": "e",
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Fatalf("got %#v, want %#v", v, want)
+	}
+}
+
+func TestConvertNumbers(t *testing.T) {
+	decoder := NewTokenDecoder(strings.NewReader(`[["ts",9,8],["v",4,4]]`))
+	decoder.UseNumber()
+	var columnMeta [][]interface{}
+	if err := decoder.Decode(&columnMeta); err != nil {
+		t.Fatal(err)
+	}
+	ConvertNumbers(&columnMeta)
+	if n, ok := columnMeta[1][1].(Number); !ok || n != "4" {
+		t.Fatalf("got %#v, want Number 4", columnMeta[1][1])
+	}
+}
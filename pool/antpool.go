@@ -3,10 +3,14 @@ package pool
 import (
 	"github.com/panjf2000/ants/v2"
 	"github.com/taosdata/go-utils/pool/config"
+	"sync/atomic"
 )
 
 var GoroutinePool *ants.Pool
 
+// waiting counts the Submit calls blocked on a full GoroutinePool, ants v2.4 does not report them.
+var waiting int64
+
 func init() {
 	var err error
 	GoroutinePool, err = ants.NewPool(config.GoPoolSize)
@@ -14,3 +18,16 @@ func init() {
 		panic(err)
 	}
 }
+
+// Submit runs task on GoroutinePool, blocking like GoroutinePool.Submit while the pool is full.
+// The blocked calls are reported by Waiting and the goroutine_pool_waiting metric.
+func Submit(task func()) error {
+	atomic.AddInt64(&waiting, 1)
+	defer atomic.AddInt64(&waiting, -1)
+	return GoroutinePool.Submit(task)
+}
+
+// Waiting returns the Submit calls waiting for a free worker.
+func Waiting() int {
+	return int(atomic.LoadInt64(&waiting))
+}
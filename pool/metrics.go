@@ -0,0 +1,33 @@
+package pool
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	goroutinePoolRunningDesc = prometheus.NewDesc("goroutine_pool_running", "Running workers of the goroutine pool.", nil, nil)
+	goroutinePoolFreeDesc    = prometheus.NewDesc("goroutine_pool_free", "Available workers of the goroutine pool.", nil, nil)
+	goroutinePoolCapDesc     = prometheus.NewDesc("goroutine_pool_capacity", "Capacity of the goroutine pool.", nil, nil)
+	goroutinePoolWaitingDesc = prometheus.NewDesc("goroutine_pool_waiting", "Submit calls waiting for a free worker of the goroutine pool.", nil, nil)
+)
+
+// Collector returns a Prometheus collector of the GoroutinePool stats, register it with prometheus.MustRegister(pool.Collector()).
+func Collector() prometheus.Collector {
+	return goroutinePoolCollector{}
+}
+
+type goroutinePoolCollector struct{}
+
+func (goroutinePoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- goroutinePoolRunningDesc
+	ch <- goroutinePoolFreeDesc
+	ch <- goroutinePoolCapDesc
+	ch <- goroutinePoolWaitingDesc
+}
+
+func (goroutinePoolCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(goroutinePoolRunningDesc, prometheus.GaugeValue, float64(GoroutinePool.Running()))
+	ch <- prometheus.MustNewConstMetric(goroutinePoolFreeDesc, prometheus.GaugeValue, float64(GoroutinePool.Free()))
+	ch <- prometheus.MustNewConstMetric(goroutinePoolCapDesc, prometheus.GaugeValue, float64(GoroutinePool.Cap()))
+	ch <- prometheus.MustNewConstMetric(goroutinePoolWaitingDesc, prometheus.GaugeValue, float64(Waiting()))
+}
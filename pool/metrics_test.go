@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorWaiting(t *testing.T) {
+	saved := GoroutinePool
+	defer func() { GoroutinePool = saved }()
+	var err error
+	GoroutinePool, err = ants.NewPool(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer GoroutinePool.Release()
+
+	block := make(chan struct{})
+	if err = Submit(func() { <-block }); err != nil {
+		t.Fatal(err)
+	}
+	submitted := make(chan error)
+	go func() { submitted <- Submit(func() {}) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for Waiting() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("the second Submit is not counted as waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	expected := `
+# HELP goroutine_pool_capacity Capacity of the goroutine pool.
+# TYPE goroutine_pool_capacity gauge
+goroutine_pool_capacity 1
+# HELP goroutine_pool_free Available workers of the goroutine pool.
+# TYPE goroutine_pool_free gauge
+goroutine_pool_free 0
+# HELP goroutine_pool_running Running workers of the goroutine pool.
+# TYPE goroutine_pool_running gauge
+goroutine_pool_running 1
+# HELP goroutine_pool_waiting Submit calls waiting for a free worker of the goroutine pool.
+# TYPE goroutine_pool_waiting gauge
+goroutine_pool_waiting 1
+`
+	if err = testutil.CollectAndCompare(Collector(), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	close(block)
+	if err = <-submitted; err != nil {
+		t.Fatal(err)
+	}
+	if Waiting() != 0 {
+		t.Fatalf("got %d waiting after the Submit returned", Waiting())
+	}
+}
@@ -7,8 +7,31 @@ const (
 	TaosdAuthType = "Taosd"
 )
 const (
-	TDengineRestfulConnectorType = "restful"
-	TDengineGoConnectorType      = "go"
+	TDengineRestfulConnectorType   = "restful"
+	TDengineGoConnectorType        = "go"
+	TDengineWebSocketConnectorType = "websocket"
+)
+
+const (
+	RoundRobinBalance    = "round_robin"
+	LeastInFlightBalance = "least_in_flight"
+)
+
+const (
+	UTCTimestampFormat    = "utc"
+	StringTimestampFormat = "string"
+	EpochTimestampFormat  = "epoch"
+)
+
+const (
+	GzipCompression    = "gzip"
+	DeflateCompression = "deflate"
+)
+
+const (
+	InfluxDBLineProtocol   = "influxdb"
+	OpenTSDBTelnetProtocol = "opentsdb_telnet"
+	OpenTSDBJSONProtocol   = "opentsdb_json"
 )
 
 type TDengineError struct {
@@ -0,0 +1,10 @@
+package common
+
+import "time"
+
+// Health is the state of a connection to TDengine, Authenticated is false when the server rejected the credentials.
+type Health struct {
+	ServerVersion string        `json:"server_version"`
+	RoundTrip     time.Duration `json:"round_trip"`
+	Authenticated bool          `json:"authenticated"`
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	taosErrors "github.com/taosdata/driver-go/errors"
+)
+
+// retriableCodes are TDengine error codes caused by the cluster state rather than the request,
+// such as a lost connection, a node that is not ready yet or a leader change.
+var retriableCodes = map[int32]bool{
+	taosErrors.RPC_REDIRECT:             true,
+	taosErrors.RPC_NOT_READY:            true,
+	taosErrors.RPC_TOO_SLOW:             true,
+	taosErrors.RPC_MAX_SESSIONS:         true,
+	taosErrors.RPC_NETWORK_UNAVAIL:      true,
+	taosErrors.APP_NOT_READY:            true,
+	taosErrors.TSC_DISCONNECTED:         true,
+	taosErrors.MND_NOT_READY:            true,
+	taosErrors.MND_VGROUP_NOT_READY:     true,
+	taosErrors.VND_IS_FLOWCTRL:          true,
+	taosErrors.VND_IS_BALANCING:         true,
+	taosErrors.VND_IS_CLOSING:           true,
+	taosErrors.VND_NOT_SYNCED:           true,
+	taosErrors.VND_IS_SYNCING:           true,
+	taosErrors.VND_NO_WRITE_AUTH:        true,
+	taosErrors.QRY_NOT_READY:            true,
+	taosErrors.HTTP_SERVER_OFFLINE:      true,
+	taosErrors.MND_TOO_MANY_SHELL_CONNS: true,
+}
+
+// HTTPStatusError is returned when taosAdapter answers with an unexpected HTTP status.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return e.Body
+}
+
+// IsRetriable reports whether err is transient and the request may succeed when sent again:
+// timeouts, refused, reset and broken connections, responses cut short and the TDengine codes of retriableCodes.
+// Context cancellation and deadlines are never retriable, SQL, certificate and TLS errors are fatal,
+// as are the other errors of http.Client such as an unsupported scheme.
+func IsRetriable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var tdengineError *TDengineError
+	if errors.As(err, &tdengineError) {
+		return retriableCodes[int32(tdengineError.Code&0xffff)]
+	}
+	var statusError *HTTPStatusError
+	if errors.As(err, &statusError) {
+		switch statusError.StatusCode {
+		case 429, 502, 503, 504:
+			return true
+		}
+		return false
+	}
+	if isTLSError(err) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
+
+// isTLSError reports whether err is a certificate verification or TLS protocol failure, sending again gets the same.
+func isTLSError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalid          x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		systemRoots      x509.SystemRootsError
+		recordHeader     tls.RecordHeaderError
+	)
+	return errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname) ||
+		errors.As(err, &systemRoots) || errors.As(err, &recordHeader)
+}
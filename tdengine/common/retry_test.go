@@ -0,0 +1,66 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	taosErrors "github.com/taosdata/driver-go/errors"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Post", URL: "http://127.0.0.1:6041/rest/sql", Err: err}
+}
+
+func syscallError(errno syscall.Errno) error {
+	return urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: errno}})
+}
+
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: urlError(context.Canceled), want: false},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: false},
+		{name: "timeout", err: urlError(&net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}), want: true},
+		{name: "connection refused", err: syscallError(syscall.ECONNREFUSED), want: true},
+		{name: "connection reset", err: syscallError(syscall.ECONNRESET), want: true},
+		{name: "broken pipe", err: syscallError(syscall.EPIPE), want: true},
+		{name: "unexpected EOF", err: urlError(io.ErrUnexpectedEOF), want: true},
+		{name: "EOF", err: io.EOF, want: false},
+		{name: "unknown authority", err: urlError(x509.UnknownAuthorityError{}), want: false},
+		{name: "hostname", err: urlError(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "taosadapter"}), want: false},
+		{name: "invalid certificate", err: urlError(x509.CertificateInvalidError{Reason: x509.Expired}), want: false},
+		{name: "TLS record header", err: urlError(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), want: false},
+		{name: "unsupported scheme", err: urlError(errors.New(`unsupported protocol scheme "htp"`)), want: false},
+		{name: "malformed URL", err: &url.Error{Op: "parse", URL: "http://[::1", Err: errors.New("missing ']' in host")}, want: false},
+		{name: "retriable code", err: &TDengineError{Code: int(taosErrors.RPC_NETWORK_UNAVAIL), Desc: "Unable to establish connection"}, want: true},
+		{name: "SQL error", err: &TDengineError{Code: 0x2600, Desc: "syntax error"}, want: false},
+		{name: "service unavailable", err: &HTTPStatusError{StatusCode: 503}, want: true},
+		{name: "bad request", err: &HTTPStatusError{StatusCode: 400}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetriable(tt.err); got != tt.want {
+				t.Fatalf("IsRetriable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+// CircuitBreaker configures the circuit breaker around a connector.
+// The circuit opens when at least MinRequests calls were made in Window and the rate of failed ones reaches FailureRate,
+// it stays open for Cooldown and then lets HalfOpenRequests trial calls through to decide whether to close again.
+type CircuitBreaker struct {
+	FailureRate      float64       `default:"0.5"`
+	MinRequests      int           `default:"10"`
+	Window           time.Duration `default:"10s"`
+	Cooldown         time.Duration `default:"30s"`
+	HalfOpenRequests int           `default:"1"`
+}
+
+// Init fills the zero fields from the TDENGINE_BREAKER_ environment variables and the default tags,
+// the returned config.Errors lists every invalid variable.
+func (conf *CircuitBreaker) Init() error {
+	return initFromEnv(conf, "TDENGINE_BREAKER")
+}
+
+func (conf *CircuitBreaker) Validate() error {
+	var errs errorList
+	if conf.FailureRate <= 0 || conf.FailureRate > 1 {
+		errs.add("failure_rate must be greater than 0 and at most 1, got %g", conf.FailureRate)
+	}
+	if conf.MinRequests < 1 {
+		errs.add("min_requests must be at least 1, got %d", conf.MinRequests)
+	}
+	if conf.Window <= 0 || conf.Cooldown <= 0 {
+		errs.add("window and cooldown must be positive, got %s and %s", conf.Window, conf.Cooldown)
+	}
+	if conf.HalfOpenRequests < 1 {
+		errs.add("half_open_requests must be at least 1, got %d", conf.HalfOpenRequests)
+	}
+	return errs.err()
+}
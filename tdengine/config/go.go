@@ -1,62 +1,30 @@
 package config
 
-import (
-	"os"
-	"strconv"
-)
-
 type TDengineGo struct {
-	Address     string
-	MaxIdle     int
-	MaxOpen     int
-	MaxLifetime int
+	// Address 的环境变量与 restful 相同为 TDENGINE_ADDRESS，TDENGINE_ADDR 已废弃
+	Address     string `env:"ADDRESS,ADDR" default:"root:taosdata@/tcp(127.0.0.1:6030)/" secret:"true"`
+	MaxIdle     int    `default:"20"`
+	MaxOpen     int    `default:"200"`
+	MaxLifetime int    `env:"MAX_LIFETIME,MAX_LIFE_TIME" default:"30"`
+	// Precision 数据库的时间精度 ms、us 或 ns，用于参数绑定时转换 time.Time
+	Precision string `default:"ms"`
+	Retry     Retry
 }
 
-func (t *TDengineGo) Init() {
-	if t.Address == "" {
-		if addr := os.Getenv("TDENGINE_ADDR"); addr != "" {
-			t.Address = addr
-		}
-		if t.Address == "" {
-			t.Address = "root:taosdata@/tcp(127.0.0.1:6030)/"
-		}
-	}
-
-	if t.MaxIdle == 0 {
-		if val := os.Getenv("TDENGINE_MAX_IDLE"); val != "" {
-			i, err := strconv.Atoi(val)
-			if err != nil {
-				panic(val)
-			}
-			t.MaxIdle = i
-		}
-		if t.MaxIdle == 0 {
-			t.MaxIdle = 20
-		}
-	}
+// Init fills the zero fields from the TDENGINE_ environment variables and the default tags, Retry included,
+// the returned config.Errors lists every invalid variable.
+func (t *TDengineGo) Init() error {
+	return initFromEnv(t, "TDENGINE")
+}
 
-	if t.MaxOpen == 0 {
-		if val := os.Getenv("TDENGINE_MAX_OPEN"); val != "" {
-			i, err := strconv.Atoi(val)
-			if err != nil {
-				panic(err)
-			}
-			t.MaxOpen = i
-		}
-		if t.MaxOpen == 0 {
-			t.MaxOpen = 200
-		}
+func (t *TDengineGo) Validate() error {
+	var errs errorList
+	if t.Address == "" {
+		errs.add("address is required")
 	}
-	if t.MaxLifetime == 0 {
-		if val := os.Getenv("TDENGINE_MAX_LIFE_TIME"); val != "" {
-			i, err := strconv.Atoi(val)
-			if err != nil {
-				panic(err)
-			}
-			t.MaxLifetime = i
-		}
-		if t.MaxLifetime == 0 {
-			t.MaxLifetime = 30
-		}
+	errs.oneOf("precision", t.Precision, "ms", "us", "ns")
+	if t.MaxIdle < 0 || t.MaxOpen < 0 || t.MaxLifetime < 0 {
+		errs.add("max_idle, max_open and max_lifetime must not be negative")
 	}
+	return errs.err()
 }
@@ -0,0 +1,9 @@
+package config
+
+import loader "github.com/taosdata/go-utils/config"
+
+// initFromEnv is the Init methods: the fields still zero are filled from the environment variables of prefix
+// and the default tags by the config loader, so an invalid variable is reported instead of panicking.
+func initFromEnv(conf interface{}, prefix string) error {
+	return loader.Load(conf, &loader.Options{EnvPrefix: prefix, KeepSet: true})
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	loader "github.com/taosdata/go-utils/config"
+)
+
+func TestInit(t *testing.T) {
+	t.Setenv("TDENGINE_USERNAME", "env")
+	t.Setenv("TDENGINE_PASSWORD", "env")
+	t.Setenv("TDENGINE_RETRY_MAX_ATTEMPTS", "5")
+	conf := &TDengineRestful{Address: "http://a:6041,http://b:6041", Username: "root"}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Username != "root" || conf.Password != "env" || conf.AuthType != "Basic" || conf.DialTimeout != 30*time.Second {
+		t.Fatalf("got %s:%s, %s and %s, want the set value over the environment over the defaults",
+			conf.Username, conf.Password, conf.AuthType, conf.DialTimeout)
+	}
+	if conf.Retry.MaxAttempts != 5 || len(conf.Addresses) != 2 {
+		t.Fatalf("got %d attempts and addresses %v", conf.Retry.MaxAttempts, conf.Addresses)
+	}
+}
+
+func TestInitInvalidEnv(t *testing.T) {
+	t.Setenv("TDENGINE_BREAKER_WINDOW", "soon")
+	t.Setenv("TDENGINE_BREAKER_MIN_REQUESTS", "many")
+	err := (&CircuitBreaker{}).Init()
+	var errs loader.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("got %v, want both invalid variables", err)
+	}
+	var fieldErr *loader.FieldError
+	if !errs.As(&fieldErr) || fieldErr.Source != "TDENGINE_BREAKER_MIN_REQUESTS" {
+		t.Fatalf("got %v, want the variable as the source", fieldErr)
+	}
+}
+
+func TestInitDeprecatedEnv(t *testing.T) {
+	t.Setenv("TDENGINE_ADDR", "old:old@/tcp(old:6030)/")
+	t.Setenv("TDENGINE_MAX_LIFE_TIME", "60")
+	t.Setenv("TDENGINE_AUTHTYPE", "Taosd")
+	goConf := &TDengineGo{}
+	if err := goConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if goConf.Address != "old:old@/tcp(old:6030)/" || goConf.MaxLifetime != 60 {
+		t.Fatalf("got %s and %d, want the deprecated variables", goConf.Address, goConf.MaxLifetime)
+	}
+	t.Setenv("TDENGINE_ADDRESS", "root:taosdata@/tcp(new:6030)/")
+	t.Setenv("TDENGINE_AUTH_TYPE", "Basic")
+	goConf, restfulConf := &TDengineGo{}, &TDengineRestful{}
+	if err := goConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restfulConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if goConf.Address != "root:taosdata@/tcp(new:6030)/" || restfulConf.AuthType != "Basic" {
+		t.Fatalf("got %s and %s, want TDENGINE_ADDRESS and TDENGINE_AUTH_TYPE over the deprecated names", goConf.Address, restfulConf.AuthType)
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+// Limit configures the client side limits of a connector, zero values mean unlimited.
+// MaxInFlight caps concurrent calls and QPS the calls per second with bursts of Burst.
+// The Key limits apply to every key set with connector.WithLimitKey, such as a tenant, on top of the connector limits.
+type Limit struct {
+	MaxInFlight    int
+	QPS            float64
+	Burst          int
+	KeyMaxInFlight int
+	KeyQPS         float64
+	KeyBurst       int
+}
+
+// Init fills the zero fields from the TDENGINE_LIMIT_ environment variables like the other configs,
+// the returned config.Errors lists every invalid variable.
+func (conf *Limit) Init() error {
+	return initFromEnv(conf, "TDENGINE_LIMIT")
+}
+
+func (conf *Limit) Validate() error {
+	var errs errorList
+	if conf.MaxInFlight < 0 || conf.Burst < 0 || conf.KeyMaxInFlight < 0 || conf.KeyBurst < 0 {
+		errs.add("max_in_flight, burst, key_max_in_flight and key_burst must not be negative")
+	}
+	if conf.QPS < 0 || conf.KeyQPS < 0 {
+		errs.add("qps and key_qps must not be negative")
+	}
+	return errs.err()
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	loader "github.com/taosdata/go-utils/config"
+)
+
+func TestLimitInit(t *testing.T) {
+	t.Setenv("TDENGINE_LIMIT_MAX_IN_FLIGHT", "8")
+	t.Setenv("TDENGINE_LIMIT_QPS", "fast")
+	t.Setenv("TDENGINE_LIMIT_BURST", "many")
+	conf := &Limit{}
+	err := conf.Init()
+	if err == nil || conf.MaxInFlight != 8 {
+		t.Fatalf("got %d max in flight and error %v, want 8 and the invalid variables", conf.MaxInFlight, err)
+	}
+	var errs loader.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("got %v, want both invalid variables", err)
+	}
+}
@@ -1,58 +1,85 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/taosdata/go-utils/tdengine/common"
 )
 
 type TDengineRestful struct {
-	Address         string
-	AuthType        string
-	Username        string
-	Password        string
-	MaxConnsPerHost int
+	// Address 可以是逗号分隔的多个地址，Addresses 为空时由 Address 拆分得到
+	Address         string `default:"http://127.0.0.1:6041"`
+	AuthType        string `env:"AUTH_TYPE,AUTHTYPE" default:"Basic"`
+	Username        string `default:"root"`
+	Password        string `default:"taosdata"`
+	MaxConnsPerHost int    `default:"-1"`
+	Addresses       []string
+	LoadBalance     string `default:"round_robin"`
+	// HealthCheckInterval 多个地址时检查被摘除地址是否恢复的间隔
+	HealthCheckInterval time.Duration `default:"10s"`
+	// TokenRefreshInterval Taosd 认证时定期刷新 token 的间隔，0 表示只在鉴权失败时刷新
+	TokenRefreshInterval time.Duration
+	TLS                  TLS
+	// 连接相关超时，ResponseHeaderTimeout 为 0 表示不限制
+	DialTimeout           time.Duration `default:"30s"`
+	KeepAlive             time.Duration `default:"30s"`
+	IdleConnTimeout       time.Duration `default:"90s"`
+	ResponseHeaderTimeout time.Duration
+	TLSHandshakeTimeout   time.Duration `default:"10s"`
+	MaxIdleConns          int           `default:"100"`
+	// Transport 不为空时替代默认的 http.Transport，TLS、超时和 MaxConnsPerHost 设置不再生效
+	Transport http.RoundTripper
+	// Compression 为 gzip 或 deflate 时压缩请求体并接受压缩的响应，deflate 按 HTTP 的定义为 zlib 格式而非原始 DEFLATE 数据
+	Compression string
+	// TimestampFormat 为 utc、string 或 epoch，分别使用 /rest/sqlutc、/rest/sql 和 /rest/sqlt
+	TimestampFormat string `default:"utc"`
+	// Precision 数据库的时间精度 ms、us 或 ns，epoch 格式按此转换时间戳
+	Precision string `default:"ms"`
+	// Database 不为空时作为 SQL 的默认数据库
+	Database string
+	Retry    Retry
 }
 
-func (conf *TDengineRestful) Init() {
-	if conf.Address == "" {
-		if val := os.Getenv("TDENGINE_ADDRESS"); val != "" {
-			conf.Address = val
-		} else {
-			conf.Address = "http://127.0.0.1:6041"
-		}
+// Init fills the zero fields from the TDENGINE_ environment variables and the default tags, TLS and Retry included,
+// then splits Address into Addresses. The returned config.Errors lists every invalid variable.
+func (conf *TDengineRestful) Init() error {
+	err := initFromEnv(conf, "TDENGINE")
+	conf.Addresses = conf.AddressList()
+	return err
+}
+
+// Validate checks the values Init or the config loader filled, TLS and Retry are checked by their own Validate.
+func (conf *TDengineRestful) Validate() error {
+	var errs errorList
+	if len(conf.AddressList()) == 0 {
+		errs.add("address is required")
 	}
-	if conf.AuthType == "" {
-		if val := os.Getenv("TDENGINE_AUTHTYPE"); val != "" {
-			conf.AuthType = val
-		} else {
-			conf.AuthType = common.BasicAuthType
-		}
+	errs.oneOf("auth_type", conf.AuthType, common.BasicAuthType, common.TaosdAuthType)
+	errs.oneOf("load_balance", conf.LoadBalance, common.RoundRobinBalance, common.LeastInFlightBalance)
+	errs.oneOf("compression", conf.Compression, "", common.GzipCompression, common.DeflateCompression)
+	errs.oneOf("timestamp_format", conf.TimestampFormat, common.UTCTimestampFormat, common.StringTimestampFormat, common.EpochTimestampFormat)
+	errs.oneOf("precision", conf.Precision, "ms", "us", "ns")
+	if conf.HealthCheckInterval < 0 || conf.TokenRefreshInterval < 0 {
+		errs.add("health_check_interval and token_refresh_interval must not be negative")
 	}
-	if conf.Username == "" {
-		if val := os.Getenv("TDENGINE_USERNAME"); val != "" {
-			conf.Username = val
-		} else {
-			conf.Username = "root"
-		}
+	if conf.MaxIdleConns < 0 {
+		errs.add("max_idle_conns must not be negative, got %d", conf.MaxIdleConns)
 	}
-	if conf.Password == "" {
-		if val := os.Getenv("TDENGINE_PASSWORD"); val != "" {
-			conf.Password = val
-		} else {
-			conf.Password = "taosdata"
-		}
+	return errs.err()
+}
+
+// AddressList returns Addresses, or the comma separated addresses of Address when Addresses is empty.
+func (conf *TDengineRestful) AddressList() []string {
+	if len(conf.Addresses) != 0 {
+		return conf.Addresses
 	}
-	if conf.MaxConnsPerHost == 0 {
-		if val := os.Getenv("TDENGINE_MAX_CONNS_PER_HOST"); val != "" {
-			v, err := strconv.Atoi(val)
-			if err != nil {
-				panic(err)
-			}
-			conf.MaxConnsPerHost = v
-		} else {
-			conf.MaxConnsPerHost = -1
+	var addresses []string
+	for _, address := range strings.Split(conf.Address, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
 		}
 	}
+	return addresses
 }
@@ -0,0 +1,36 @@
+package config
+
+import "time"
+
+// Retry configures how connectors retry transient failures, MaxAttempts 1 disables retrying.
+type Retry struct {
+	MaxAttempts    int           `default:"3"`
+	InitialBackoff time.Duration `default:"100ms"`
+	MaxBackoff     time.Duration `default:"2s"`
+	// BudgetTokens 和 BudgetRatio 限制重试总量：每次重试消耗一个 token，每次请求归还 BudgetRatio 个 token，最多 BudgetTokens 个
+	BudgetTokens int     `default:"10"`
+	BudgetRatio  float64 `default:"0.1"`
+}
+
+// Init fills the zero fields from the TDENGINE_RETRY_ environment variables and the default tags,
+// the returned config.Errors lists every invalid variable.
+func (conf *Retry) Init() error {
+	return initFromEnv(conf, "TDENGINE_RETRY")
+}
+
+func (conf *Retry) Validate() error {
+	var errs errorList
+	if conf.MaxAttempts < 1 {
+		errs.add("max_attempts must be at least 1, got %d", conf.MaxAttempts)
+	}
+	if conf.InitialBackoff < 0 || conf.MaxBackoff < conf.InitialBackoff {
+		errs.add("initial_backoff must not be negative nor greater than max_backoff, got %s and %s", conf.InitialBackoff, conf.MaxBackoff)
+	}
+	if conf.BudgetTokens < 0 {
+		errs.add("budget_tokens must not be negative, got %d", conf.BudgetTokens)
+	}
+	if conf.BudgetRatio < 0 || conf.BudgetRatio > 1 {
+		errs.add("budget_ratio must be between 0 and 1, got %g", conf.BudgetRatio)
+	}
+	return errs.err()
+}
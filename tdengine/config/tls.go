@@ -0,0 +1,63 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+)
+
+// TLS configures the client side of a TLS connection, CertFile and KeyFile enable mutual TLS.
+type TLS struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// Init fills the zero fields from the TDENGINE_TLS_ environment variables,
+// the returned config.Errors lists every invalid variable.
+func (conf *TLS) Init() error {
+	return initFromEnv(conf, "TDENGINE_TLS")
+}
+
+func (conf *TLS) Validate() error {
+	if (conf.CertFile == "") != (conf.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// ClientConfig builds the tls.Config, it returns nil when nothing is configured so the defaults of net/http apply.
+func (conf *TLS) ClientConfig() (*tls.Config, error) {
+	if conf.CAFile == "" && conf.CertFile == "" && conf.KeyFile == "" && conf.ServerName == "" && !conf.InsecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+	if conf.CAFile != "" {
+		ca, err := ioutil.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate found in " + conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		if conf.CertFile == "" || conf.KeyFile == "" {
+			return nil, errors.New("both TLS cert file and key file are required")
+		}
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
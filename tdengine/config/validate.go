@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errorList collects the problems of a Validate method, its Unwrap lets the config loader report each of them.
+type errorList []error
+
+func (e *errorList) add(format string, args ...interface{}) {
+	*e = append(*e, fmt.Errorf(format, args...))
+}
+
+// oneOf adds an error when value is not one of allowed.
+func (e *errorList) oneOf(name, value string, allowed ...string) {
+	for _, v := range allowed {
+		if value == v {
+			return
+		}
+	}
+	quoted := make([]string, len(allowed))
+	for i, v := range allowed {
+		quoted[i] = strconv.Quote(v)
+	}
+	e.add("%s must be one of %s, got %q", name, strings.Join(quoted, ", "), value)
+}
+
+func (e errorList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e errorList) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e errorList) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+type TDengineWebSocket struct {
+	Address  string `env:"WS_ADDRESS" default:"ws://127.0.0.1:6041"`
+	Username string `default:"root"`
+	Password string `default:"taosdata"`
+	MaxIdle  int    `env:"WS_MAX_IDLE" default:"10"`
+	// MaxIdleTime 超过此时间未使用的连接可能已被 taosAdapter 或中间的代理关闭，不再复用
+	MaxIdleTime time.Duration `env:"WS_MAX_IDLE_TIME" default:"5m"`
+	// Database 不为空时作为连接的默认数据库
+	Database string
+}
+
+// Init fills the zero fields from the TDENGINE_ environment variables and the default tags,
+// the returned config.Errors lists every invalid variable.
+func (conf *TDengineWebSocket) Init() error {
+	return initFromEnv(conf, "TDENGINE")
+}
+
+func (conf *TDengineWebSocket) Validate() error {
+	var errs errorList
+	if conf.Address == "" {
+		errs.add("address is required")
+	}
+	if conf.MaxIdle < 0 {
+		errs.add("max_idle must not be negative, got %d", conf.MaxIdle)
+	}
+	if conf.MaxIdleTime < 0 {
+		errs.add("max_idle_time must not be negative, got %s", conf.MaxIdleTime)
+	}
+	return errs.err()
+}
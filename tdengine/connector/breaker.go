@@ -0,0 +1,224 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling TDengine while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+var (
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tdengine_circuit_breaker_state",
+		Help: "1 for the current state of the circuit breaker of a connector type, 0 for the other states.",
+	}, []string{"connector", "state"})
+	breakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tdengine_circuit_breaker_transitions_total",
+		Help: "State changes of the circuit breaker of a connector type by new state.",
+	}, []string{"connector", "state"})
+	breakerRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tdengine_circuit_breaker_rejected_total",
+		Help: "Calls rejected with ErrCircuitOpen by connector type.",
+	}, []string{"connector"})
+)
+
+// CircuitBreaker stops calling TDengine once too many calls fail, so callers fail fast instead of waiting out timeouts.
+// Only failures telling TDengine is unavailable count: the ones common.IsRetriable accepts and exceeded deadlines.
+// SQL errors count as successes, cancelled calls are ignored.
+type CircuitBreaker struct {
+	conf   config.CircuitBreaker
+	logger Logger
+	name   string
+	now    func() time.Time
+
+	lock             sync.Mutex
+	state            string
+	windowStart      time.Time
+	requests         int
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+	halfOpenSucceed  int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker, state changes are logged to logger when it is not nil.
+// Its Interceptor wraps a connector, a breaker is usually dedicated to one connector.
+// Zero or invalid values of conf, such as a config not passed through Init, use the defaults of config.CircuitBreaker.
+func NewCircuitBreaker(conf *config.CircuitBreaker, logger Logger) *CircuitBreaker {
+	registerMetrics()
+	b := &CircuitBreaker{conf: breakerConfig(conf), logger: logger, now: time.Now, state: CircuitClosed}
+	b.windowStart = b.now()
+	return b
+}
+
+// CircuitBreakerInterceptor wraps every connector in its own circuit breaker,
+// register it with RegisterInterceptor to protect every connector.
+func CircuitBreakerInterceptor(conf *config.CircuitBreaker, logger Logger) Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		return NewCircuitBreaker(conf, logger).Interceptor()(next)
+	}
+}
+
+func breakerConfig(conf *config.CircuitBreaker) config.CircuitBreaker {
+	result := *conf
+	// 零值会使断路器一直打开或每次调用都重置窗口
+	if result.FailureRate <= 0 || result.FailureRate > 1 {
+		result.FailureRate = 0.5
+	}
+	if result.MinRequests < 1 {
+		result.MinRequests = 10
+	}
+	if result.Window <= 0 {
+		result.Window = 10 * time.Second
+	}
+	if result.Cooldown <= 0 {
+		result.Cooldown = 30 * time.Second
+	}
+	if result.HalfOpenRequests < 1 {
+		result.HalfOpenRequests = 1
+	}
+	return result
+}
+
+// State returns CircuitClosed, CircuitOpen or CircuitHalfOpen, an open circuit past its cooldown changes to half open.
+func (b *CircuitBreaker) State() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.conf.Cooldown {
+		b.halfOpen()
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) Interceptor() Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		b.lock.Lock()
+		b.name = ConnectorType(next)
+		b.setState(b.state)
+		b.lock.Unlock()
+		return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			trial, err := b.allow()
+			if err != nil {
+				breakerRejected.WithLabelValues(b.name).Inc()
+				return err
+			}
+			err = invoke(ctx)
+			b.record(trial, err)
+			return err
+		})(next)
+	}
+}
+
+// allow reports whether a call may go through, trial is true for the calls testing a half open circuit.
+func (b *CircuitBreaker) allow() (trial bool, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := b.now()
+	switch b.state {
+	case CircuitClosed:
+		if now.Sub(b.windowStart) >= b.conf.Window {
+			b.resetWindow(now)
+		}
+		return false, nil
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.conf.Cooldown {
+			return false, ErrCircuitOpen
+		}
+		b.halfOpen()
+	}
+	if b.halfOpenInFlight >= b.conf.HalfOpenRequests {
+		return false, ErrCircuitOpen
+	}
+	b.halfOpenInFlight++
+	return true, nil
+}
+
+func (b *CircuitBreaker) record(trial bool, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	// 取消的请求不能说明 TDengine 的状态，超时则说明 TDengine 没有及时响应
+	ignored := errors.Is(err, context.Canceled)
+	failed := common.IsRetriable(err) || errors.Is(err, context.DeadlineExceeded)
+	now := b.now()
+	if trial {
+		if b.state != CircuitHalfOpen {
+			return
+		}
+		b.halfOpenInFlight--
+		switch {
+		case ignored:
+		case failed:
+			b.open(now)
+		default:
+			b.halfOpenSucceed++
+			if b.halfOpenSucceed >= b.conf.HalfOpenRequests {
+				b.resetWindow(now)
+				b.setState(CircuitClosed)
+			}
+		}
+		return
+	}
+	if b.state != CircuitClosed || ignored {
+		return
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.conf.MinRequests && float64(b.failures) >= b.conf.FailureRate*float64(b.requests) {
+		b.open(now)
+	}
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(CircuitOpen)
+}
+
+func (b *CircuitBreaker) halfOpen() {
+	b.halfOpenInFlight = 0
+	b.halfOpenSucceed = 0
+	b.setState(CircuitHalfOpen)
+}
+
+func (b *CircuitBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+// setState changes the state and reports the change, it is called with the lock held.
+func (b *CircuitBreaker) setState(state string) {
+	previous := b.state
+	b.state = state
+	if b.name == "" {
+		return
+	}
+	for _, s := range []string{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		breakerState.WithLabelValues(b.name, s).Set(value)
+	}
+	if previous == state {
+		return
+	}
+	breakerTransitions.WithLabelValues(b.name, state).Inc()
+	if b.logger != nil {
+		b.logger.Info(fmt.Sprintf("circuit breaker of %s connector changed from %s to %s", b.name, previous, state))
+	}
+}
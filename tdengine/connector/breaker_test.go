@@ -0,0 +1,66 @@
+package connector
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+func newTestCircuitBreaker(conf *config.CircuitBreaker) (*CircuitBreaker, *time.Time) {
+	now := time.Unix(1626000000, 0)
+	b := NewCircuitBreaker(conf, nil)
+	b.now = func() time.Time { return now }
+	b.windowStart = now
+	return b, &now
+}
+
+func TestCircuitBreakerDefaults(t *testing.T) {
+	b, now := newTestCircuitBreaker(&config.CircuitBreaker{})
+	want := config.CircuitBreaker{FailureRate: 0.5, MinRequests: 10, Window: 10 * time.Second, Cooldown: 30 * time.Second, HalfOpenRequests: 1}
+	if b.conf != want {
+		t.Fatalf("got config %+v, want the defaults %+v", b.conf, want)
+	}
+	for i := 0; i < 9; i++ {
+		if _, err := b.allow(); err != nil {
+			t.Fatal(err)
+		}
+		b.record(false, syscall.ECONNREFUSED)
+		*now = now.Add(time.Millisecond)
+	}
+	if state := b.State(); state != CircuitClosed {
+		t.Fatalf("circuit is %s after 9 failures, want closed until min_requests", state)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	b, now := newTestCircuitBreaker(&config.CircuitBreaker{MinRequests: 2, Cooldown: time.Second})
+	for i := 0; i < 2; i++ {
+		if _, err := b.allow(); err != nil {
+			t.Fatal(err)
+		}
+		b.record(false, syscall.ECONNREFUSED)
+	}
+	if state := b.State(); state != CircuitOpen {
+		t.Fatalf("circuit is %s, want open", state)
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("got error %v, want ErrCircuitOpen", err)
+	}
+	*now = now.Add(time.Second)
+	if state := b.State(); state != CircuitHalfOpen || b.state != CircuitHalfOpen {
+		t.Fatalf("State returned %s with the breaker %s, want both half open", state, b.state)
+	}
+	trial, err := b.allow()
+	if err != nil || !trial {
+		t.Fatalf("got trial %v, %v, want a trial call", trial, err)
+	}
+	if _, err = b.allow(); err != ErrCircuitOpen {
+		t.Fatalf("got error %v beyond half_open_requests, want ErrCircuitOpen", err)
+	}
+	b.record(true, nil)
+	if state := b.State(); state != CircuitClosed {
+		t.Fatalf("circuit is %s after a successful trial, want closed", state)
+	}
+}
@@ -1,13 +1,170 @@
 package connector
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"github.com/taosdata/go-utils/json"
+	"strings"
+)
 
+// Data is a query result, every connector returns the same Go types for the same TDengine types:
+//
+//	BOOL                  bool
+//	TINYINT               int8
+//	SMALLINT              int16
+//	INT                   int32
+//	BIGINT                int64
+//	TINYINT UNSIGNED      uint8
+//	SMALLINT UNSIGNED     uint16
+//	INT UNSIGNED          uint32
+//	BIGINT UNSIGNED       uint64
+//	FLOAT                 float32
+//	DOUBLE                float64
+//	BINARY (VARCHAR)      string
+//	NCHAR                 string
+//	TIMESTAMP             time.Time
+//	JSON                  string holding the JSON text
+//	VARBINARY             []byte
+//	GEOMETRY              []byte holding WKB
+//
+// NULL is nil whatever the type.
 type Data struct {
-	Head []string        `json:"head"`
-	Data [][]interface{} `json:"data"`
+	Head    []string        `json:"head"`
+	Data    [][]interface{} `json:"data"`
+	Columns []*Column       `json:"columns"`
+}
+
+// TDengine column type codes, as found in Column.Type.
+const (
+	TypeBool             = 1
+	TypeTinyInt          = 2
+	TypeSmallInt         = 3
+	TypeInt              = 4
+	TypeBigInt           = 5
+	TypeFloat            = 6
+	TypeDouble           = 7
+	TypeBinary           = 8
+	TypeTimestamp        = 9
+	TypeNChar            = 10
+	TypeUnsignedTinyInt  = 11
+	TypeUnsignedSmallInt = 12
+	TypeUnsignedInt      = 13
+	TypeUnsignedBigInt   = 14
+	TypeJSON             = 15
+	TypeVarBinary        = 16
+	TypeGeometry         = 20
+)
+
+var typeNames = map[int]string{
+	TypeBool:             "BOOL",
+	TypeTinyInt:          "TINYINT",
+	TypeSmallInt:         "SMALLINT",
+	TypeInt:              "INT",
+	TypeBigInt:           "BIGINT",
+	TypeFloat:            "FLOAT",
+	TypeDouble:           "DOUBLE",
+	TypeBinary:           "BINARY",
+	TypeTimestamp:        "TIMESTAMP",
+	TypeNChar:            "NCHAR",
+	TypeUnsignedTinyInt:  "TINYINT UNSIGNED",
+	TypeUnsignedSmallInt: "SMALLINT UNSIGNED",
+	TypeUnsignedInt:      "INT UNSIGNED",
+	TypeUnsignedBigInt:   "BIGINT UNSIGNED",
+	TypeJSON:             "JSON",
+	TypeVarBinary:        "VARBINARY",
+	TypeGeometry:         "GEOMETRY",
+}
+
+// Column describes a result column: its name, TDengine type code and type length.
+type Column struct {
+	Name   string `json:"name"`
+	Type   int    `json:"type"`
+	Length int    `json:"length"`
+}
+
+// TypeName returns the TDengine name of the column type, such as BIGINT or NCHAR.
+func (c *Column) TypeName() string {
+	if name, ok := typeNames[c.Type]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// typeCode returns the type code of a TDengine type name, 0 when unknown.
+func typeCode(name string) int {
+	if name == "VARCHAR" {
+		return TypeBinary
+	}
+	for code, typeName := range typeNames {
+		if typeName == name {
+			return code
+		}
+	}
+	return 0
 }
 
 type TDengineConnector interface {
 	Exec(ctx context.Context, sql string) (int64, error)
 	Query(ctx context.Context, sql string) (*Data, error)
+	// QueryRows streams the result of sql row by row instead of loading it into memory.
+	QueryRows(ctx context.Context, sql string) (Rows, error)
+	// Prepare creates a statement whose ? placeholders are bound on every Exec and Query.
+	Prepare(ctx context.Context, sql string) (Stmt, error)
+	// ExecArgs and QueryArgs bind args to the ? placeholders of sql, strings never need quoting by the caller.
+	ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error)
+	QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error)
+}
+
+// SchemalessWriter is implemented by connectors that can write InfluxDB line protocol and OpenTSDB data directly,
+// TDengine creates and alters the super tables and sub tables as needed.
+// protocol is one of common.InfluxDBLineProtocol, common.OpenTSDBTelnetProtocol and common.OpenTSDBJSONProtocol,
+// precision is one of ns, us, ms, s, m and h, it only applies to InfluxDB line protocol and may be empty for nanosecond.
+// With common.OpenTSDBJSONProtocol every line is a JSON document, an object or an array of objects,
+// they are merged into one array.
+type SchemalessWriter interface {
+	SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error
+}
+
+// joinOpenTSDBJSON merges OpenTSDB JSON documents into one JSON array, a single document is returned as is.
+func joinOpenTSDBJSON(lines []string) (string, error) {
+	if len(lines) == 1 {
+		return lines[0], nil
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	count := 0
+	for i, line := range lines {
+		var items []json.RawMessage
+		var err error
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal([]byte(trimmed), &items)
+		} else {
+			var item json.RawMessage
+			err = json.Unmarshal([]byte(trimmed), &item)
+			items = []json.RawMessage{item}
+		}
+		if err != nil {
+			return "", fmt.Errorf("OpenTSDB JSON document %d: %w", i, err)
+		}
+		for _, item := range items {
+			if count > 0 {
+				b.WriteByte(',')
+			}
+			b.Write(item)
+			count++
+		}
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+// Rows is a forward-only cursor over a query result. Values are converted the same way as Query.
+// Rows must be closed to release the underlying connection or response body.
+type Rows interface {
+	Columns() []string
+	ColumnTypes() []*Column
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
 }
@@ -0,0 +1,306 @@
+package connectortest
+
+import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/connector"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+// Cassette is the recorded traffic of a connector, saved as JSON.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded call, Method and SQL are the same as Statement.
+// DB and Precision are only set for SchemalessWrite.
+type Interaction struct {
+	Method    string         `json:"method"`
+	SQL       string         `json:"sql"`
+	DB        string         `json:"db,omitempty"`
+	Precision string         `json:"precision,omitempty"`
+	Args      []*Value       `json:"args,omitempty"`
+	Data      *CassetteData  `json:"data,omitempty"`
+	Affected  int64          `json:"affected,omitempty"`
+	Error     *CassetteError `json:"error,omitempty"`
+	Latency   time.Duration  `json:"latency"`
+}
+
+// CassetteData is a connector.Data keeping the Go type of every value.
+type CassetteData struct {
+	Head    []string            `json:"head"`
+	Columns []*connector.Column `json:"columns"`
+	Data    [][]*Value          `json:"data"`
+}
+
+// Value is a typed value, Type is the Go type name and Value its exact text form,
+// time.Time is RFC 3339 with nanoseconds in UTC and []byte is base64. A nil *Value is nil.
+// json.Number is recorded as number, the maps and slices of decoded JSON values as json.
+type Value struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// CassetteError keeps enough of an error to return an equivalent one on replay.
+// Kind is tdengine, http_status, canceled, deadline_exceeded or other.
+type CassetteError struct {
+	Kind    string `json:"kind"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// LoadCassette reads a cassette saved by Cassette.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := &Cassette{}
+	if err = json.Unmarshal(b, cassette); err != nil {
+		return nil, fmt.Errorf("load cassette %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to path as indented JSON.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func encodeValue(v interface{}) (*Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return &Value{"bool", strconv.FormatBool(v)}, nil
+	case int8:
+		return &Value{"int8", strconv.FormatInt(int64(v), 10)}, nil
+	case int16:
+		return &Value{"int16", strconv.FormatInt(int64(v), 10)}, nil
+	case int32:
+		return &Value{"int32", strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return &Value{"int64", strconv.FormatInt(v, 10)}, nil
+	case int:
+		return &Value{"int", strconv.FormatInt(int64(v), 10)}, nil
+	case uint8:
+		return &Value{"uint8", strconv.FormatUint(uint64(v), 10)}, nil
+	case uint16:
+		return &Value{"uint16", strconv.FormatUint(uint64(v), 10)}, nil
+	case uint32:
+		return &Value{"uint32", strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return &Value{"uint64", strconv.FormatUint(v, 10)}, nil
+	case uint:
+		return &Value{"uint", strconv.FormatUint(uint64(v), 10)}, nil
+	case float32:
+		return &Value{"float32", strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case float64:
+		return &Value{"float64", strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case string:
+		return &Value{"string", v}, nil
+	case []byte:
+		return &Value{"bytes", base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		// UTC 保证不同时区录制的参数在回放时一致
+		return &Value{"time", v.UTC().Format(time.RFC3339Nano)}, nil
+	case json.Number:
+		return &Value{"number", v.String()}, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(literalNumbers(v))
+		if err != nil {
+			return nil, fmt.Errorf("encode cassette value %T: %w", v, err)
+		}
+		return &Value{"json", string(b)}, nil
+	}
+	return nil, fmt.Errorf("unsupported cassette value type %T, the supported types are the ones of connector.Data", v)
+}
+
+// literalNumbers copies v with json.Number replaced by the encoding/json Number, which is marshaled as a JSON number.
+func literalNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return stdjson.Number(v)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = literalNumbers(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = literalNumbers(value)
+		}
+		return result
+	}
+	return v
+}
+
+// Interface returns the value with its recorded Go type.
+func (v *Value) Interface() (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch v.Type {
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "int8":
+		i, err := strconv.ParseInt(v.Value, 10, 8)
+		return int8(i), err
+	case "int16":
+		i, err := strconv.ParseInt(v.Value, 10, 16)
+		return int16(i), err
+	case "int32":
+		i, err := strconv.ParseInt(v.Value, 10, 32)
+		return int32(i), err
+	case "int64":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "int":
+		i, err := strconv.ParseInt(v.Value, 10, 64)
+		return int(i), err
+	case "uint8":
+		i, err := strconv.ParseUint(v.Value, 10, 8)
+		return uint8(i), err
+	case "uint16":
+		i, err := strconv.ParseUint(v.Value, 10, 16)
+		return uint16(i), err
+	case "uint32":
+		i, err := strconv.ParseUint(v.Value, 10, 32)
+		return uint32(i), err
+	case "uint64":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "uint":
+		i, err := strconv.ParseUint(v.Value, 10, 64)
+		return uint(i), err
+	case "float32":
+		f, err := strconv.ParseFloat(v.Value, 32)
+		return float32(f), err
+	case "float64":
+		return strconv.ParseFloat(v.Value, 64)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "number":
+		return json.Number(v.Value), nil
+	case "json":
+		var value interface{}
+		err := json.UnmarshalUseNumber([]byte(v.Value), &value)
+		return value, err
+	}
+	return nil, fmt.Errorf("unsupported cassette value type %s", v.Type)
+}
+
+func encodeValues(values []interface{}) ([]*Value, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make([]*Value, len(values))
+	for i, v := range values {
+		value, err := encodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func encodeData(data *connector.Data) (*CassetteData, error) {
+	if data == nil {
+		return nil, nil
+	}
+	result := &CassetteData{Head: data.Head, Columns: data.Columns, Data: make([][]*Value, len(data.Data))}
+	for i, row := range data.Data {
+		values, err := encodeValues(row)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []*Value{}
+		}
+		result.Data[i] = values
+	}
+	return result, nil
+}
+
+// ToData converts the recorded result back to a connector.Data.
+func (d *CassetteData) ToData() (*connector.Data, error) {
+	data := &connector.Data{Head: d.Head, Columns: d.Columns, Data: make([][]interface{}, len(d.Data))}
+	for i, row := range d.Data {
+		data.Data[i] = make([]interface{}, len(row))
+		for j, value := range row {
+			v, err := value.Interface()
+			if err != nil {
+				return nil, err
+			}
+			data.Data[i][j] = v
+		}
+	}
+	return data, nil
+}
+
+func encodeError(err error) *CassetteError {
+	if err == nil {
+		return nil
+	}
+	var tdengineError *common.TDengineError
+	if errors.As(err, &tdengineError) {
+		return &CassetteError{Kind: "tdengine", Code: tdengineError.Code, Message: tdengineError.Desc}
+	}
+	var statusError *common.HTTPStatusError
+	if errors.As(err, &statusError) {
+		return &CassetteError{Kind: "http_status", Code: statusError.StatusCode, Message: statusError.Body}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &CassetteError{Kind: "canceled", Message: err.Error()}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &CassetteError{Kind: "deadline_exceeded", Message: err.Error()}
+	}
+	return &CassetteError{Kind: "other", Message: err.Error()}
+}
+
+// Err returns an error equivalent to the recorded one, nil for a nil *CassetteError.
+func (e *CassetteError) Err() error {
+	if e == nil {
+		return nil
+	}
+	switch e.Kind {
+	case "tdengine":
+		return &common.TDengineError{Code: e.Code, Desc: e.Message}
+	case "http_status":
+		return &common.HTTPStatusError{StatusCode: e.Code, Body: e.Message}
+	case "canceled":
+		return context.Canceled
+	case "deadline_exceeded":
+		return context.DeadlineExceeded
+	}
+	return errors.New(e.Message)
+}
+
+func equalValues(a []*Value, b []*Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i] == nil) != (b[i] == nil) || a[i] != nil && *a[i] != *b[i] {
+			return false
+		}
+	}
+	return true
+}
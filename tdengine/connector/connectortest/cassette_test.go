@@ -0,0 +1,148 @@
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/connector"
+)
+
+func TestValue(t *testing.T) {
+	ts := time.Date(2022, 1, 1, 8, 0, 0, 123456789, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name  string
+		value interface{}
+		want  *Value
+	}{
+		{name: "nil", value: nil, want: nil},
+		{name: "bool", value: true, want: &Value{"bool", "true"}},
+		{name: "int8", value: int8(-8), want: &Value{"int8", "-8"}},
+		{name: "uint64", value: uint64(18446744073709551614), want: &Value{"uint64", "18446744073709551614"}},
+		{name: "float32", value: float32(1.5), want: &Value{"float32", "1.5"}},
+		{name: "string", value: "中文", want: &Value{"string", "中文"}},
+		{name: "bytes", value: []byte{1, 2, 0xff}, want: &Value{"bytes", "AQL/"}},
+		{name: "time", value: ts, want: &Value{"time", "2022-01-01T00:00:00.123456789Z"}},
+		{name: "number", value: json.Number("18446744073709551615"), want: &Value{"number", "18446744073709551615"}},
+		{name: "map", value: map[string]interface{}{"k": json.Number("1")}, want: &Value{"json", `{"k":1}`}},
+		{name: "slice", value: []interface{}{"a", json.Number("2.5")}, want: &Value{"json", `["a",2.5]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeValue(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("encoded %v, want %v", got, tt.want)
+			}
+			decoded, err := got.Interface()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, ok := tt.value.(time.Time); ok {
+				if !decoded.(time.Time).Equal(want) {
+					t.Fatalf("decoded %v, want %v", decoded, want)
+				}
+				return
+			}
+			if !reflect.DeepEqual(decoded, tt.value) {
+				t.Fatalf("decoded %#v, want %#v", decoded, tt.value)
+			}
+		})
+	}
+	if _, err := encodeValue(struct{}{}); err == nil {
+		t.Fatal("encoded an unsupported type")
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	data := &connector.Data{
+		Head:    []string{"ts", "v", "info"},
+		Columns: []*connector.Column{{Name: "ts", Type: connector.TypeTimestamp}, {Name: "v", Type: connector.TypeInt}, {Name: "info", Type: connector.TypeJSON}},
+		Data:    [][]interface{}{{time.Unix(1626000000, 0), int32(1), map[string]interface{}{"k": "v"}}},
+	}
+	fake := NewFake()
+	fake.On("select * from t where ts >= ?").Return(data)
+	fake.On("insert into t values(now, 1)").ReturnAffected(1)
+	fake.On("drop table t").ReturnTDengineError(0x2662, "Table does not exist")
+	recorder := NewRecorder(fake)
+	ts := time.Unix(1626000000, 0).In(time.FixedZone("CST", 8*3600))
+	if _, err := recorder.QueryArgs(ctx, "select * from t where ts >= ?", ts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.Exec(ctx, "insert into t values(now, 1)"); err != nil {
+		t.Fatal(err)
+	}
+	_, dropErr := recorder.Exec(ctx, "drop table t")
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayer(cassette)
+	got, err := replayer.QueryArgs(ctx, "select * from t where ts >= ?", ts.UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Data[0][0].(time.Time).Equal(data.Data[0][0].(time.Time)) || !reflect.DeepEqual(got.Data[0][1:], data.Data[0][1:]) {
+		t.Fatalf("replayed %v, want %v", got.Data, data.Data)
+	}
+	if affected, err := replayer.Exec(ctx, "insert into t values(now, 1)"); err != nil || affected != 1 {
+		t.Fatalf("replayed Exec %d, %v", affected, err)
+	}
+	if _, err = replayer.Exec(ctx, "drop table t"); err == nil || err.Error() != dropErr.Error() {
+		t.Fatalf("replayed error %v, want %v", err, dropErr)
+	}
+	if remaining := replayer.Remaining(); len(remaining) != 0 {
+		t.Fatalf("%d interactions not replayed", len(remaining))
+	}
+}
+
+func TestRecordReplaySchemaless(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.On("influxdb").ReturnAffected(0)
+	recorder := NewRecorder(fake)
+	lines := []string{"m,t=1 v=1i 1626000000000"}
+	if err := recorder.SchemalessWrite(ctx, "db", "influxdb", lines, "ms"); err != nil {
+		t.Fatal(err)
+	}
+	cassette, err := recorder.Cassette()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interaction := cassette.Interactions[0]; interaction.DB != "db" || interaction.Precision != "ms" {
+		t.Fatalf("recorded db %q and precision %q", interaction.DB, interaction.Precision)
+	}
+	replayer := NewReplayer(cassette)
+	for _, call := range []struct{ db, precision string }{{"other", "ms"}, {"db", "ns"}} {
+		if err = replayer.SchemalessWrite(ctx, call.db, "influxdb", lines, call.precision); !errors.Is(err, ErrUnexpected) {
+			t.Fatalf("replayed %s with %s got %v, want ErrUnexpected", call.db, call.precision, err)
+		}
+	}
+	if err = replayer.SchemalessWrite(ctx, "db", "influxdb", lines, "ms"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayCanceled(t *testing.T) {
+	replayer := NewReplayer(&Cassette{Interactions: []*Interaction{{Method: "Exec", SQL: "insert into t values(now, 1)", Affected: 1}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := replayer.Exec(ctx, "insert into t values(now, 1)"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if remaining := replayer.Remaining(); len(remaining) != 1 {
+		t.Fatal("a canceled call used the interaction")
+	}
+}
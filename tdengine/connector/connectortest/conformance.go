@@ -0,0 +1,192 @@
+// Package connectortest provides helpers to check TDengine connectors.
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/connector"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ConformanceOptions enables the types that need a recent server.
+// GoConnector supports none of them, the taosSql driver it is built on has no JSON, VARBINARY or GEOMETRY support,
+// so it is checked with the zero options.
+type ConformanceOptions struct {
+	JSON      bool
+	VarBinary bool
+	Geometry  bool
+}
+
+type conformanceColumn struct {
+	name     string
+	define   string
+	literal  string
+	expected interface{}
+}
+
+var conformanceTime = time.Unix(1626000000, 123*int64(time.Millisecond))
+
+func conformanceColumns(options *ConformanceOptions) []*conformanceColumn {
+	columns := []*conformanceColumn{
+		{"c_bool", "bool", "true", true},
+		{"c_tinyint", "tinyint", "-8", int8(-8)},
+		{"c_smallint", "smallint", "-16", int16(-16)},
+		{"c_int", "int", "-32", int32(-32)},
+		{"c_bigint", "bigint", "-9007199254740993", int64(-9007199254740993)},
+		{"c_utinyint", "tinyint unsigned", "8", uint8(8)},
+		{"c_usmallint", "smallint unsigned", "16", uint16(16)},
+		{"c_uint", "int unsigned", "32", uint32(32)},
+		{"c_ubigint", "bigint unsigned", "18446744073709551614", uint64(18446744073709551614)},
+		{"c_float", "float", "1.5", float32(1.5)},
+		{"c_double", "double", "2.25", float64(2.25)},
+		{"c_binary", "binary(32)", `'bin\'"\\'`, `bin'"\`},
+		{"c_nchar", "nchar(32)", "'中文 nchar'", "中文 nchar"},
+	}
+	if options.VarBinary {
+		columns = append(columns, &conformanceColumn{"c_varbinary", "varbinary(16)", `'\x0102ff'`, []byte{1, 2, 0xff}})
+	}
+	if options.Geometry {
+		columns = append(columns, &conformanceColumn{"c_geometry", "geometry(64)", "'POINT(1 2)'", []byte{
+			0x01, 0x01, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f,
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40,
+		}})
+	}
+	return columns
+}
+
+// RunConformance checks that c returns the value types documented on connector.Data.
+// It creates the super table conformance in db, which must use millisecond precision,
+// writes a row with a value for every type and a row of NULLs, then reads them back through Query, QueryRows and QueryArgs.
+func RunConformance(ctx context.Context, c connector.TDengineConnector, db string, options *ConformanceOptions) error {
+	if options == nil {
+		options = &ConformanceOptions{}
+	}
+	columns := conformanceColumns(options)
+	defines := []string{"ts timestamp"}
+	literals := []string{fmt.Sprintf("%d", conformanceTime.UnixNano()/int64(time.Millisecond))}
+	nulls := []string{fmt.Sprintf("%d", conformanceTime.UnixNano()/int64(time.Millisecond)+1)}
+	for _, column := range columns {
+		defines = append(defines, column.name+" "+column.define)
+		literals = append(literals, column.literal)
+		nulls = append(nulls, "null")
+	}
+	tag, tagValue := "t_int int", "1"
+	if options.JSON {
+		tag, tagValue = "t_json json", `'{"k":"v"}'`
+	}
+	statements := []string{
+		fmt.Sprintf("drop stable if exists %s.conformance", db),
+		fmt.Sprintf("create stable %s.conformance (%s) tags (%s)", db, strings.Join(defines, ","), tag),
+		fmt.Sprintf("insert into %s.conformance_1 using %s.conformance tags (%s) values (%s) (%s)",
+			db, db, tagValue, strings.Join(literals, ","), strings.Join(nulls, ",")),
+	}
+	for _, sql := range statements {
+		if _, err := c.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("%s: %w", sql, err)
+		}
+	}
+	query := fmt.Sprintf("select * from %s.conformance order by ts", db)
+	data, err := c.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("Query: %w", err)
+	}
+	if err = checkData("Query", data, columns, options); err != nil {
+		return err
+	}
+	rows, err := c.QueryRows(ctx, query)
+	if err != nil {
+		return fmt.Errorf("QueryRows: %w", err)
+	}
+	data = &connector.Data{Head: rows.Columns(), Columns: rows.ColumnTypes()}
+	for rows.Next() {
+		values := make([]interface{}, len(data.Head))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			rows.Close()
+			return fmt.Errorf("QueryRows: %w", err)
+		}
+		data.Data = append(data.Data, values)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("QueryRows: %w", err)
+	}
+	if err = checkData("QueryRows", data, columns, options); err != nil {
+		return err
+	}
+	data, err = c.QueryArgs(ctx, fmt.Sprintf("select * from %s.conformance where ts >= ? order by ts", db), conformanceTime)
+	if err != nil {
+		return fmt.Errorf("QueryArgs: %w", err)
+	}
+	return checkData("QueryArgs", data, columns, options)
+}
+
+func checkData(method string, data *connector.Data, columns []*conformanceColumn, options *ConformanceOptions) error {
+	if len(data.Data) != 2 {
+		return fmt.Errorf("%s: got %d rows, want 2", method, len(data.Data))
+	}
+	if len(data.Columns) != len(data.Head) {
+		return fmt.Errorf("%s: got %d column types for %d columns", method, len(data.Columns), len(data.Head))
+	}
+	index := make(map[string]int, len(data.Head))
+	for i, name := range data.Head {
+		index[name] = i
+	}
+	valueRow, nullRow := data.Data[0], data.Data[1]
+	ts, ok := valueRow[index["ts"]].(time.Time)
+	if !ok || !ts.Equal(conformanceTime) {
+		return fmt.Errorf("%s: ts is %T %v, want time.Time %v", method, valueRow[index["ts"]], valueRow[index["ts"]], conformanceTime)
+	}
+	if options.JSON {
+		i, ok := index["t_json"]
+		if !ok {
+			return fmt.Errorf("%s: missing column t_json", method)
+		}
+		if err := checkJSON(valueRow[i], `{"k":"v"}`); err != nil {
+			return fmt.Errorf("%s: t_json %w", method, err)
+		}
+	}
+	for _, column := range columns {
+		i, ok := index[column.name]
+		if !ok {
+			return fmt.Errorf("%s: missing column %s", method, column.name)
+		}
+		if data.Columns[i].Name != column.name {
+			return fmt.Errorf("%s: column type of %s is named %s", method, column.name, data.Columns[i].Name)
+		}
+		if !reflect.DeepEqual(valueRow[i], column.expected) {
+			return fmt.Errorf("%s: %s is %T %v, want %T %v", method, column.name, valueRow[i], valueRow[i], column.expected, column.expected)
+		}
+		if nullRow[i] != nil {
+			return fmt.Errorf("%s: %s is %T %v, want nil", method, column.name, nullRow[i], nullRow[i])
+		}
+	}
+	return nil
+}
+
+func checkJSON(value interface{}, expected string) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("is %T, want string", value)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal([]byte(s), &got); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, want) {
+		return errors.New("is " + s + ", want " + expected)
+	}
+	return nil
+}
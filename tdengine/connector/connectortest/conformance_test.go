@@ -0,0 +1,106 @@
+package connectortest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/config"
+	"github.com/taosdata/go-utils/tdengine/connector"
+)
+
+var allTypes = &ConformanceOptions{JSON: true, VarBinary: true, Geometry: true}
+
+// conformanceTypes are the column_meta types of the conformance columns.
+var conformanceTypes = map[string]int{
+	"c_bool":      connector.TypeBool,
+	"c_tinyint":   connector.TypeTinyInt,
+	"c_smallint":  connector.TypeSmallInt,
+	"c_int":       connector.TypeInt,
+	"c_bigint":    connector.TypeBigInt,
+	"c_utinyint":  connector.TypeUnsignedTinyInt,
+	"c_usmallint": connector.TypeUnsignedSmallInt,
+	"c_uint":      connector.TypeUnsignedInt,
+	"c_ubigint":   connector.TypeUnsignedBigInt,
+	"c_float":     connector.TypeFloat,
+	"c_double":    connector.TypeDouble,
+	"c_binary":    connector.TypeBinary,
+	"c_nchar":     connector.TypeNChar,
+	"c_varbinary": connector.TypeVarBinary,
+	"c_geometry":  connector.TypeGeometry,
+	"t_json":      connector.TypeJSON,
+	"ts":          connector.TypeTimestamp,
+}
+
+func TestConformanceFake(t *testing.T) {
+	columns := conformanceColumns(allTypes)
+	data := &connector.Data{Head: []string{"ts"}, Columns: []*connector.Column{{Name: "ts", Type: connector.TypeTimestamp}}}
+	values, nulls := []interface{}{conformanceTime}, []interface{}{conformanceTime.Add(1e6)}
+	for _, column := range columns {
+		data.Head = append(data.Head, column.name)
+		data.Columns = append(data.Columns, &connector.Column{Name: column.name, Type: conformanceTypes[column.name]})
+		values = append(values, column.expected)
+		nulls = append(nulls, nil)
+	}
+	data.Head = append(data.Head, "t_json")
+	data.Columns = append(data.Columns, &connector.Column{Name: "t_json", Type: connector.TypeJSON})
+	data.Data = [][]interface{}{append(values, `{"k":"v"}`), append(nulls, `{"k":"v"}`)}
+
+	fake := NewFake()
+	fake.OnRegexp(`^(drop|create|insert) `).ReturnAffected(1)
+	fake.OnRegexp(`^select \* from db\.conformance `).Return(data)
+	if err := RunConformance(context.Background(), fake, "db", allTypes); err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertExpectationsMet(t)
+}
+
+// restfulConformanceBody is what taosAdapter answers to the conformance query on /rest/sqlutc, in column order.
+var restfulConformanceBody = func() string {
+	names := []string{"ts", "c_bool", "c_tinyint", "c_smallint", "c_int", "c_bigint", "c_utinyint", "c_usmallint", "c_uint", "c_ubigint",
+		"c_float", "c_double", "c_binary", "c_nchar", "c_varbinary", "c_geometry", "t_json"}
+	meta := make([]string, len(names))
+	nulls := make([]string, len(names))
+	for i, name := range names {
+		meta[i] = `["` + name + `",` + strconv.Itoa(conformanceTypes[name]) + `,8]`
+		nulls[i] = "null"
+	}
+	nulls[0] = `"2021-07-11T10:40:00.124+0000"`
+	nulls[len(nulls)-1] = `{"k":"v"}`
+	values := []string{`"2021-07-11T10:40:00.123+0000"`, "true", "-8", "-16", "-32", "-9007199254740993", "8", "16", "32", "18446744073709551614",
+		"1.5", "2.25", `"bin'\"\\"`, `"中文 nchar"`, `"\\x0102ff"`, `"0101000000000000000000f03f0000000000000040"`, `{"k":"v"}`}
+	return `{"code":0,"column_meta":[` + strings.Join(meta, ",") + `],"data":[[` + strings.Join(values, ",") + `],[` +
+		strings.Join(nulls, ",") + `]],"rows":2,"status":"succ"}`
+}()
+
+func TestConformanceRestful(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sql, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/rest/sqlutc" {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasPrefix(string(sql), "select") {
+			_, _ = w.Write([]byte(restfulConformanceBody))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"column_meta":[["affected_rows",4,4]],"data":[[1]],"rows":1,"status":"succ"}`))
+	}))
+	defer server.Close()
+	conf := &config.TDengineRestful{Address: server.URL}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := connector.NewRestfulConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err = RunConformance(context.Background(), c, "db", allTypes); err != nil {
+		t.Fatal(err)
+	}
+}
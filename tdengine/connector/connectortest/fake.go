@@ -0,0 +1,305 @@
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/connector"
+	"regexp"
+	"sync"
+)
+
+// Statement is a call recorded by Fake, Method is the connector method such as Exec, QueryArgs or Stmt.Exec.
+type Statement struct {
+	Method string
+	SQL    string
+	Args   []interface{}
+}
+
+// ErrUnexpected is returned, wrapped, for a statement matching no expectation.
+var ErrUnexpected = errors.New("unexpected statement")
+
+// Expectation is a scripted response, created by Fake.On and Fake.OnRegexp.
+type Expectation struct {
+	sql      string
+	pattern  *regexp.Regexp
+	data     *connector.Data
+	affected int64
+	err      error
+	times    int
+	calls    int
+}
+
+// Return makes queries matching the expectation return data.
+func (e *Expectation) Return(data *connector.Data) *Expectation {
+	e.data = data
+	return e
+}
+
+// ReturnAffected makes executions matching the expectation return affected rows.
+func (e *Expectation) ReturnAffected(affected int64) *Expectation {
+	e.affected = affected
+	return e
+}
+
+// ReturnError makes statements matching the expectation fail with err.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// ReturnTDengineError makes statements matching the expectation fail with a common.TDengineError.
+func (e *Expectation) ReturnTDengineError(code int, desc string) *Expectation {
+	return e.ReturnError(&common.TDengineError{Code: code, Desc: desc})
+}
+
+// Times limits the expectation to n matches, the default 0 means unlimited.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) match(sql string) bool {
+	if e.times > 0 && e.calls >= e.times {
+		return false
+	}
+	if e.pattern != nil {
+		return e.pattern.MatchString(sql)
+	}
+	return e.sql == sql
+}
+
+func (e *Expectation) String() string {
+	if e.pattern != nil {
+		return "regexp " + e.pattern.String()
+	}
+	return e.sql
+}
+
+// Fake is an in-memory connector.TDengineConnector recording every statement and answering with scripted responses.
+// Expectations are matched in the order they were added, a statement matching none fails with ErrUnexpected.
+// Statements with args are matched by their text with the ? placeholders.
+type Fake struct {
+	lock         sync.Mutex
+	expectations []*Expectation
+	statements   []*Statement
+	health       *common.Health
+	healthErr    error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// On adds an expectation matching sql exactly.
+func (f *Fake) On(sql string) *Expectation {
+	e := &Expectation{sql: sql}
+	f.lock.Lock()
+	f.expectations = append(f.expectations, e)
+	f.lock.Unlock()
+	return e
+}
+
+// OnRegexp adds an expectation matching sql against pattern, it panics if pattern does not compile.
+func (f *Fake) OnRegexp(pattern string) *Expectation {
+	e := &Expectation{pattern: regexp.MustCompile(pattern)}
+	f.lock.Lock()
+	f.expectations = append(f.expectations, e)
+	f.lock.Unlock()
+	return e
+}
+
+// SetHealth sets the result of Ping and Health, the fake is healthy by default.
+func (f *Fake) SetHealth(health *common.Health, err error) {
+	f.lock.Lock()
+	f.health = health
+	f.healthErr = err
+	f.lock.Unlock()
+}
+
+func (f *Fake) Ping(ctx context.Context) error {
+	_, err := f.Health(ctx)
+	return err
+}
+
+func (f *Fake) Health(ctx context.Context) (*common.Health, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.health == nil && f.healthErr == nil {
+		return &common.Health{ServerVersion: "fake", Authenticated: true}, nil
+	}
+	return f.health, f.healthErr
+}
+
+// Statements returns the recorded statements in call order.
+func (f *Fake) Statements() []Statement {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result := make([]Statement, len(f.statements))
+	for i, statement := range f.statements {
+		result[i] = *statement
+	}
+	return result
+}
+
+// Reset drops the expectations and the recorded statements.
+func (f *Fake) Reset() {
+	f.lock.Lock()
+	f.expectations = nil
+	f.statements = nil
+	f.lock.Unlock()
+}
+
+func (f *Fake) handle(ctx context.Context, method string, sql string, args []interface{}) (*Expectation, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.statements = append(f.statements, &Statement{Method: method, SQL: sql, Args: args})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, e := range f.expectations {
+		if e.match(sql) {
+			e.calls++
+			return e, e.err
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrUnexpected, method, sql)
+}
+
+func (f *Fake) exec(ctx context.Context, method string, sql string, args []interface{}) (int64, error) {
+	e, err := f.handle(ctx, method, sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return e.affected, nil
+}
+
+func (f *Fake) query(ctx context.Context, method string, sql string, args []interface{}) (*connector.Data, error) {
+	e, err := f.handle(ctx, method, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.data == nil {
+		return &connector.Data{Head: []string{}}, nil
+	}
+	return e.data, nil
+}
+
+func (f *Fake) Exec(ctx context.Context, sql string) (int64, error) {
+	return f.exec(ctx, "Exec", sql, nil)
+}
+
+func (f *Fake) Query(ctx context.Context, sql string) (*connector.Data, error) {
+	return f.query(ctx, "Query", sql, nil)
+}
+
+func (f *Fake) QueryRows(ctx context.Context, sql string) (connector.Rows, error) {
+	data, err := f.query(ctx, "QueryRows", sql, nil)
+	if err != nil {
+		return nil, err
+	}
+	return connector.NewDataRows(data), nil
+}
+
+// Prepare records the statement without matching it, the expectations are matched by Stmt.Exec and Stmt.Query.
+func (f *Fake) Prepare(ctx context.Context, sql string) (connector.Stmt, error) {
+	f.lock.Lock()
+	f.statements = append(f.statements, &Statement{Method: "Prepare", SQL: sql})
+	f.lock.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &fakeStmt{fake: f, sql: sql}, nil
+}
+
+func (f *Fake) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return f.exec(ctx, "ExecArgs", sql, args)
+}
+
+func (f *Fake) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*connector.Data, error) {
+	return f.query(ctx, "QueryArgs", sql, args)
+}
+
+func (f *Fake) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	_, err := f.handle(ctx, "SchemalessWrite", protocol, linesArgs(lines))
+	return err
+}
+
+type fakeStmt struct {
+	fake   *Fake
+	sql    string
+	closed bool
+}
+
+func (s *fakeStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	if s.closed {
+		return 0, errors.New("statement is closed")
+	}
+	return s.fake.exec(ctx, "Stmt.Exec", s.sql, args)
+}
+
+func (s *fakeStmt) Query(ctx context.Context, args ...interface{}) (*connector.Data, error) {
+	if s.closed {
+		return nil, errors.New("statement is closed")
+	}
+	return s.fake.query(ctx, "Stmt.Query", s.sql, args)
+}
+
+func (s *fakeStmt) Close() error {
+	s.closed = true
+	return nil
+}
+
+// TestingT is the subset of *testing.T used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertExecuted reports an error on t unless sql was recorded.
+func (f *Fake) AssertExecuted(t TestingT, sql string) {
+	t.Helper()
+	for _, statement := range f.Statements() {
+		if statement.SQL == sql {
+			return
+		}
+	}
+	t.Errorf("statement not executed: %s", sql)
+}
+
+// AssertExecutedRegexp reports an error on t unless a recorded statement matches pattern.
+func (f *Fake) AssertExecutedRegexp(t TestingT, pattern string) {
+	t.Helper()
+	re := regexp.MustCompile(pattern)
+	for _, statement := range f.Statements() {
+		if re.MatchString(statement.SQL) {
+			return
+		}
+	}
+	t.Errorf("no statement matching %s executed", pattern)
+}
+
+// AssertNotExecuted reports an error on t if sql was recorded.
+func (f *Fake) AssertNotExecuted(t TestingT, sql string) {
+	t.Helper()
+	for _, statement := range f.Statements() {
+		if statement.SQL == sql {
+			t.Errorf("statement executed: %s", sql)
+			return
+		}
+	}
+}
+
+// AssertExpectationsMet reports an error on t for every expectation never matched,
+// or matched fewer times than set with Times.
+func (f *Fake) AssertExpectationsMet(t TestingT) {
+	t.Helper()
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, e := range f.expectations {
+		if e.calls == 0 || e.calls < e.times {
+			t.Errorf("expectation %s matched %d times", e, e.calls)
+		}
+	}
+}
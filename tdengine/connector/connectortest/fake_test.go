@@ -0,0 +1,120 @@
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/connector"
+)
+
+// recordingT is a TestingT keeping the reported errors.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFakeExpectations(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	data := &connector.Data{Head: []string{"v"}, Data: [][]interface{}{{int32(1)}}}
+	fake.On("select v from t").Return(data)
+	fake.OnRegexp(`^insert into t `).ReturnAffected(2).Times(1)
+	fake.On("drop table t").ReturnTDengineError(0x2662, "Table does not exist")
+
+	got, err := fake.Query(ctx, "select v from t")
+	if err != nil || got != data {
+		t.Fatalf("Query returned %v, %v", got, err)
+	}
+	affected, err := fake.Exec(ctx, "insert into t values(now, 1)")
+	if err != nil || affected != 2 {
+		t.Fatalf("Exec returned %d, %v", affected, err)
+	}
+	if _, err = fake.Exec(ctx, "insert into t values(now, 2)"); !errors.Is(err, ErrUnexpected) {
+		t.Fatalf("got error %v after Times(1), want ErrUnexpected", err)
+	}
+	_, err = fake.Exec(ctx, "drop table t")
+	var tdengineError *common.TDengineError
+	if !errors.As(err, &tdengineError) || tdengineError.Code != 0x2662 {
+		t.Fatalf("got error %v, want the TDengine error", err)
+	}
+	rows, err := fake.QueryRows(ctx, "select v from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int32
+	if !rows.Next() || rows.Scan(&v) != nil || v != 1 {
+		t.Fatalf("QueryRows scanned %d", v)
+	}
+	rows.Close()
+
+	statements := fake.Statements()
+	if len(statements) != 5 || statements[0].Method != "Query" || statements[4].Method != "QueryRows" {
+		t.Fatalf("recorded %v", statements)
+	}
+	fake.AssertExecuted(t, "drop table t")
+	fake.AssertNotExecuted(t, "select 1")
+	fake.AssertExpectationsMet(t)
+}
+
+func TestFakePrepare(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.On("insert into t values(?, ?)").ReturnAffected(1).Times(1)
+	stmt, err := fake.Prepare(ctx, "insert into t values(?, ?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected, err := stmt.Exec(ctx, "2022-01-01 00:00:00", 1)
+	if err != nil || affected != 1 {
+		t.Fatalf("Stmt.Exec returned %d, %v, Prepare must not consume the expectation", affected, err)
+	}
+	stmt.Close()
+	if _, err = stmt.Exec(ctx, "2022-01-01 00:00:01", 2); err == nil {
+		t.Fatal("Stmt.Exec on a closed statement succeeded")
+	}
+	statements := fake.Statements()
+	if len(statements) != 2 || statements[0].Method != "Prepare" || statements[1].Method != "Stmt.Exec" || len(statements[1].Args) != 2 {
+		t.Fatalf("recorded %v", statements)
+	}
+	fake.AssertExpectationsMet(t)
+}
+
+func TestFakeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake := NewFake()
+	fake.OnRegexp(".*")
+	if _, err := fake.Exec(ctx, "insert into t values(now, 1)"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Exec got error %v, want context.Canceled", err)
+	}
+	if _, err := fake.Prepare(ctx, "insert into t values(?)"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Prepare got error %v, want context.Canceled", err)
+	}
+}
+
+func TestFakeAssertions(t *testing.T) {
+	fake := NewFake()
+	fake.On("select 1")
+	fake.On("select 2").Times(2)
+	_, _ = fake.Query(context.Background(), "select 2")
+	recorder := &recordingT{}
+	fake.AssertExpectationsMet(recorder)
+	fake.AssertExecuted(recorder, "select 1")
+	fake.AssertExecutedRegexp(recorder, "^select [0-9]$")
+	fake.AssertNotExecuted(recorder, "select 2")
+	if len(recorder.errors) != 4 {
+		t.Fatalf("reported %q, want the two expectations, select 1 and select 2", recorder.errors)
+	}
+	fake.Reset()
+	if len(fake.Statements()) != 0 {
+		t.Fatal("Reset kept the statements")
+	}
+}
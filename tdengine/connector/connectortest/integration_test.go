@@ -0,0 +1,68 @@
+// +build integration,!windows
+
+package connectortest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/config"
+	"github.com/taosdata/go-utils/tdengine/connector"
+)
+
+// The integration tests run against the server configured by the TDENGINE_ environment variables:
+//
+//	go test -tags integration ./tdengine/connector/connectortest/
+
+const integrationDB = "go_utils_conformance"
+
+func runIntegrationConformance(t *testing.T, c connector.TDengineConnector, options *ConformanceOptions) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := c.Exec(ctx, "create database if not exists "+integrationDB+" precision 'ms'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunConformance(ctx, c, integrationDB, options); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIntegrationConformanceGo(t *testing.T) {
+	conf := &config.TDengineGo{}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := connector.NewGoConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// taosSql 不支持 JSON、VARBINARY 和 GEOMETRY，见 ConformanceOptions
+	runIntegrationConformance(t, c, &ConformanceOptions{})
+}
+
+func TestIntegrationConformanceWebSocket(t *testing.T) {
+	conf := &config.TDengineWebSocket{}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := connector.NewWebSocketConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	runIntegrationConformance(t, c, &ConformanceOptions{JSON: true})
+}
+
+func TestIntegrationConformanceRestful(t *testing.T) {
+	conf := &config.TDengineRestful{}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := connector.NewRestfulConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	runIntegrationConformance(t, c, &ConformanceOptions{JSON: true})
+}
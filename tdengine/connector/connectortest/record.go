@@ -0,0 +1,332 @@
+package connectortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/connector"
+	"sync"
+	"time"
+)
+
+// Recorder wraps a connector and records its traffic into a Cassette.
+// QueryRows reads the whole result from the wrapped connector before returning,
+// SchemalessWrite is recorded with the protocol as SQL and the lines as Args like Fake, plus the db and precision.
+type Recorder struct {
+	next     connector.TDengineConnector
+	lock     sync.Mutex
+	cassette *Cassette
+	err      error
+}
+
+func NewRecorder(next connector.TDengineConnector) *Recorder {
+	return &Recorder{next: next, cassette: &Cassette{}}
+}
+
+// Cassette returns the recorded traffic, or the first value that could not be recorded.
+func (r *Recorder) Cassette() (*Cassette, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &Cassette{Interactions: append([]*Interaction(nil), r.cassette.Interactions...)}, nil
+}
+
+// Save writes the recorded traffic to path.
+func (r *Recorder) Save(path string) error {
+	cassette, err := r.Cassette()
+	if err != nil {
+		return err
+	}
+	return cassette.Save(path)
+}
+
+func (r *Recorder) record(method string, sql string, args []interface{}, data *connector.Data, affected int64, err error, start time.Time) {
+	r.add(&Interaction{
+		Method:   method,
+		SQL:      sql,
+		Affected: affected,
+		Error:    encodeError(err),
+		Latency:  time.Since(start),
+	}, args, data)
+}
+
+// add encodes args and data into interaction and appends it.
+func (r *Recorder) add(interaction *Interaction, args []interface{}, data *connector.Data) {
+	var encodeErr error
+	interaction.Args, encodeErr = encodeValues(args)
+	if encodeErr == nil {
+		interaction.Data, encodeErr = encodeData(data)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if encodeErr != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("record %s %s: %w", interaction.Method, interaction.SQL, encodeErr)
+		}
+		return
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+func (r *Recorder) Exec(ctx context.Context, sql string) (int64, error) {
+	start := time.Now()
+	affected, err := r.next.Exec(ctx, sql)
+	r.record("Exec", sql, nil, nil, affected, err, start)
+	return affected, err
+}
+
+func (r *Recorder) Query(ctx context.Context, sql string) (*connector.Data, error) {
+	start := time.Now()
+	data, err := r.next.Query(ctx, sql)
+	r.record("Query", sql, nil, data, 0, err, start)
+	return data, err
+}
+
+func (r *Recorder) QueryRows(ctx context.Context, sql string) (connector.Rows, error) {
+	start := time.Now()
+	data, err := readRows(r.next.QueryRows(ctx, sql))
+	r.record("QueryRows", sql, nil, data, 0, err, start)
+	if err != nil {
+		return nil, err
+	}
+	return connector.NewDataRows(data), nil
+}
+
+func (r *Recorder) Prepare(ctx context.Context, sql string) (connector.Stmt, error) {
+	start := time.Now()
+	stmt, err := r.next.Prepare(ctx, sql)
+	r.record("Prepare", sql, nil, nil, 0, err, start)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{recorder: r, stmt: stmt, sql: sql}, nil
+}
+
+func (r *Recorder) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	start := time.Now()
+	affected, err := r.next.ExecArgs(ctx, sql, args...)
+	r.record("ExecArgs", sql, args, nil, affected, err, start)
+	return affected, err
+}
+
+func (r *Recorder) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*connector.Data, error) {
+	start := time.Now()
+	data, err := r.next.QueryArgs(ctx, sql, args...)
+	r.record("QueryArgs", sql, args, data, 0, err, start)
+	return data, err
+}
+
+func (r *Recorder) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	writer, ok := r.next.(connector.SchemalessWriter)
+	if !ok {
+		return errors.New("connector does not support schemaless write")
+	}
+	start := time.Now()
+	err := writer.SchemalessWrite(ctx, db, protocol, lines, precision)
+	r.add(&Interaction{
+		Method:    "SchemalessWrite",
+		SQL:       protocol,
+		DB:        db,
+		Precision: precision,
+		Error:     encodeError(err),
+		Latency:   time.Since(start),
+	}, linesArgs(lines), nil)
+	return err
+}
+
+type recordingStmt struct {
+	recorder *Recorder
+	stmt     connector.Stmt
+	sql      string
+}
+
+func (s *recordingStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	start := time.Now()
+	affected, err := s.stmt.Exec(ctx, args...)
+	s.recorder.record("Stmt.Exec", s.sql, args, nil, affected, err, start)
+	return affected, err
+}
+
+func (s *recordingStmt) Query(ctx context.Context, args ...interface{}) (*connector.Data, error) {
+	start := time.Now()
+	data, err := s.stmt.Query(ctx, args...)
+	s.recorder.record("Stmt.Query", s.sql, args, data, 0, err, start)
+	return data, err
+}
+
+func (s *recordingStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// readRows reads a whole result, the rows are always closed.
+func readRows(rows connector.Rows, err error) (*connector.Data, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := &connector.Data{Head: rows.Columns(), Columns: rows.ColumnTypes(), Data: [][]interface{}{}}
+	for rows.Next() {
+		row := make([]interface{}, len(data.Head))
+		dest := make([]interface{}, len(row))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		data.Data = append(data.Data, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func linesArgs(lines []string) []interface{} {
+	args := make([]interface{}, len(lines))
+	for i, line := range lines {
+		args[i] = line
+	}
+	return args
+}
+
+// Replayer is a connector answering from a Cassette without any network.
+// A call is answered by the first unused interaction with the same method, SQL and args,
+// and for SchemalessWrite the same db and precision,
+// so concurrent callers such as Executor.Query may replay in a different order than recorded.
+// A call matching no interaction fails with ErrUnexpected.
+type Replayer struct {
+	// SimulateLatency makes every call wait for its recorded latency.
+	SimulateLatency bool
+	lock            sync.Mutex
+	interactions    []*Interaction
+	used            []bool
+}
+
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{interactions: cassette.Interactions, used: make([]bool, len(cassette.Interactions))}
+}
+
+// Remaining returns the interactions not replayed yet.
+func (r *Replayer) Remaining() []*Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var result []*Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			result = append(result, interaction)
+		}
+	}
+	return result
+}
+
+func (r *Replayer) replay(ctx context.Context, method string, sql string, args []interface{}) (*connector.Data, int64, error) {
+	return r.replayInteraction(ctx, &Interaction{Method: method, SQL: sql}, args)
+}
+
+// replayInteraction answers with the first unused interaction matching want and args,
+// a canceled ctx fails before any interaction is used.
+func (r *Replayer) replayInteraction(ctx context.Context, want *Interaction, args []interface{}) (*connector.Data, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	encoded, err := encodeValues(args)
+	if err != nil {
+		return nil, 0, err
+	}
+	interaction := r.take(want, encoded)
+	if interaction == nil {
+		return nil, 0, fmt.Errorf("%w: %s %s", ErrUnexpected, want.Method, want.SQL)
+	}
+	if r.SimulateLatency && interaction.Latency > 0 {
+		timer := time.NewTimer(interaction.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if interaction.Error != nil {
+		return nil, 0, interaction.Error.Err()
+	}
+	if interaction.Data == nil {
+		return nil, interaction.Affected, nil
+	}
+	data, err := interaction.Data.ToData()
+	return data, interaction.Affected, err
+}
+
+func (r *Replayer) take(want *Interaction, args []*Value) *Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, interaction := range r.interactions {
+		if !r.used[i] && interaction.Method == want.Method && interaction.SQL == want.SQL &&
+			interaction.DB == want.DB && interaction.Precision == want.Precision && equalValues(interaction.Args, args) {
+			r.used[i] = true
+			return interaction
+		}
+	}
+	return nil
+}
+
+func (r *Replayer) Exec(ctx context.Context, sql string) (int64, error) {
+	_, affected, err := r.replay(ctx, "Exec", sql, nil)
+	return affected, err
+}
+
+func (r *Replayer) Query(ctx context.Context, sql string) (*connector.Data, error) {
+	data, _, err := r.replay(ctx, "Query", sql, nil)
+	return data, err
+}
+
+func (r *Replayer) QueryRows(ctx context.Context, sql string) (connector.Rows, error) {
+	data, _, err := r.replay(ctx, "QueryRows", sql, nil)
+	if err != nil {
+		return nil, err
+	}
+	return connector.NewDataRows(data), nil
+}
+
+func (r *Replayer) Prepare(ctx context.Context, sql string) (connector.Stmt, error) {
+	if _, _, err := r.replay(ctx, "Prepare", sql, nil); err != nil {
+		return nil, err
+	}
+	return &replayStmt{replayer: r, sql: sql}, nil
+}
+
+func (r *Replayer) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	_, affected, err := r.replay(ctx, "ExecArgs", sql, args)
+	return affected, err
+}
+
+func (r *Replayer) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*connector.Data, error) {
+	data, _, err := r.replay(ctx, "QueryArgs", sql, args)
+	return data, err
+}
+
+func (r *Replayer) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	_, _, err := r.replayInteraction(ctx, &Interaction{Method: "SchemalessWrite", SQL: protocol, DB: db, Precision: precision}, linesArgs(lines))
+	return err
+}
+
+type replayStmt struct {
+	replayer *Replayer
+	sql      string
+}
+
+func (s *replayStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	_, affected, err := s.replayer.replay(ctx, "Stmt.Exec", s.sql, args)
+	return affected, err
+}
+
+func (s *replayStmt) Query(ctx context.Context, args ...interface{}) (*connector.Data, error) {
+	data, _, err := s.replayer.replay(ctx, "Stmt.Query", s.sql, args)
+	return data, err
+}
+
+func (s *replayStmt) Close() error {
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	taosErrors "github.com/taosdata/driver-go/errors"
 	"github.com/taosdata/driver-go/taosSql"
 	"github.com/taosdata/go-utils/pool"
@@ -18,12 +20,35 @@ import (
 	"time"
 )
 
+// GoConnector connects through the native client with taosSql, which has no JSON, VARBINARY or GEOMETRY support,
+// use the WebSocket or RESTful connector for those types.
 type GoConnector struct {
-	db *sql.DB
+	db        *sql.DB
+	native    *nativePool
+	precision time.Duration
+	retrier   *retrier
+}
+
+func init() {
+	MustRegister(&Registration{
+		Name:      common.TDengineGoConnectorType,
+		NewConfig: func() interface{} { return &tdengineConfig.TDengineGo{} },
+		New: func(conf interface{}) (TDengineConnector, error) {
+			return NewGoConnector(conf.(*tdengineConfig.TDengineGo))
+		},
+		FromDSN: func(dsn *tdengineConfig.DSN) (interface{}, error) { return dsn.Go() },
+	})
 }
 
 func NewGoConnector(conf *tdengineConfig.TDengineGo) (*GoConnector, error) {
-	fmt.Println(conf.Address)
+	dsn, err := parseTaosSqlDSN(conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	precision, err := parsePrecision(conf.Precision)
+	if err != nil {
+		return nil, err
+	}
 	db, err := sql.Open("taosSql", conf.Address)
 	if err != nil {
 		return nil, err
@@ -31,14 +56,30 @@ func NewGoConnector(conf *tdengineConfig.TDengineGo) (*GoConnector, error) {
 	db.SetConnMaxLifetime(time.Second * time.Duration(conf.MaxLifetime))
 	db.SetMaxIdleConns(conf.MaxIdle)
 	db.SetMaxOpenConns(conf.MaxOpen)
-	return &GoConnector{db: db}, err
+	return &GoConnector{db: db, native: newNativePool(dsn, conf.MaxIdle, conf.MaxOpen), precision: precision, retrier: newRetrier(&conf.Retry)}, err
 }
 
-func (g *GoConnector) Exec(ctx context.Context, sql string) (int64, error) {
-	var err error
-	r, err := g.db.ExecContext(ctx, sql)
+func parsePrecision(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ms":
+		return time.Millisecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ns":
+		return time.Nanosecond, nil
+	}
+	return 0, fmt.Errorf("unsupported precision %q", precision)
+}
+
+func (g *GoConnector) Exec(ctx context.Context, q string) (int64, error) {
+	var r sql.Result
+	err := g.retrier.do(ctx, func() error {
+		var err error
+		r, err = g.db.ExecContext(ctx, q)
+		return g.changeError(err)
+	})
 	if err != nil {
-		return 0, g.changeError(err)
+		return 0, err
 	}
 	return r.RowsAffected()
 }
@@ -57,14 +98,53 @@ var (
 	nullTime    = reflect.TypeOf(taosSql.NullTime{})
 	nullBool    = reflect.TypeOf(taosSql.NullBool{})
 	nullString  = reflect.TypeOf(taosSql.NullString{})
+	nullBytes   = reflect.TypeOf(nullableBytes{})
 )
 
+// nullableBytes scans binary values such as VARBINARY and GEOMETRY, the bytes are copied.
+type nullableBytes struct {
+	Inner []byte
+	Valid bool
+}
+
+func (n *nullableBytes) Scan(value interface{}) error {
+	n.Inner, n.Valid = nil, value != nil
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		n.Inner = append([]byte(nil), v...)
+	case string:
+		n.Inner = []byte(v)
+	default:
+		return fmt.Errorf("can not scan %T into nullableBytes", value)
+	}
+	return nil
+}
+
+func (n nullableBytes) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Inner, nil
+}
+
 func (g *GoConnector) Query(ctx context.Context, q string) (*Data, error) {
+	var result *Data
+	err := g.retrier.do(ctx, func() error {
+		var err error
+		result, err = g.query(ctx, q)
+		return err
+	})
+	return result, err
+}
+
+func (g *GoConnector) query(ctx context.Context, q string) (*Data, error) {
 	var err error
 	rows, err := g.db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, g.changeError(err)
 	}
+	defer rows.Close()
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, g.changeError(err)
@@ -75,39 +155,8 @@ func (g *GoConnector) Query(ctx context.Context, q string) (*Data, error) {
 	if err != nil {
 		return nil, g.changeError(err)
 	}
-	types := make([]reflect.Type, len(columns))
-	for i, columnType := range tt {
-		switch columnType.DatabaseTypeName() {
-		case "BOOL":
-			types[i] = nullBool
-		case "TINYINT":
-			types[i] = nullInt8
-		case "SMALLINT":
-			types[i] = nullInt16
-		case "INT":
-			types[i] = nullInt32
-		case "BIGINT":
-			types[i] = nullInt64
-		case "TINYINT UNSIGNED":
-			types[i] = nullUInt8
-		case "SMALLINT UNSIGNED":
-			types[i] = nullUInt16
-		case "INT UNSIGNED":
-			types[i] = nullUInt32
-		case "BIGINT UNSIGNED":
-			types[i] = nullUInt64
-		case "FLOAT":
-			types[i] = nullFloat32
-		case "DOUBLE":
-			types[i] = nullFloat64
-		case "BINARY":
-			fallthrough
-		case "NCHAR":
-			types[i] = nullString
-		case "TIMESTAMP":
-			types[i] = nullTime
-		}
-	}
+	result.Columns = goColumns(tt)
+	types := columnScanTypes(tt)
 	var dbResult [][]interface{}
 	for rows.Next() {
 		scanValues := make([]interface{}, len(columns))
@@ -120,6 +169,9 @@ func (g *GoConnector) Query(ctx context.Context, q string) (*Data, error) {
 		}
 		dbResult = append(dbResult, scanValues)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, g.changeError(err)
+	}
 	//处理速度耗时，分片处理
 	result.Data = make([][]interface{}, len(dbResult))
 	batch := 10000
@@ -156,7 +208,7 @@ func (g *GoConnector) Query(ctx context.Context, q string) (*Data, error) {
 		}
 		index := i
 		wg.Add(1)
-		poolError := pool.GoroutinePool.Submit(func() {
+		poolError := pool.Submit(func() {
 			tmp, _ := g.dealResult(len(columns), d)
 			partitionChan <- &info{
 				index:  index,
@@ -171,6 +223,220 @@ func (g *GoConnector) Query(ctx context.Context, q string) (*Data, error) {
 	return result, nil
 }
 
+func (g *GoConnector) QueryRows(ctx context.Context, q string) (Rows, error) {
+	var rows *sql.Rows
+	err := g.retrier.do(ctx, func() error {
+		var err error
+		rows, err = g.db.QueryContext(ctx, q)
+		return g.changeError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, g.changeError(err)
+	}
+	tt, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, g.changeError(err)
+	}
+	types := columnScanTypes(tt)
+	scanValues := make([]interface{}, len(columns))
+	for i := range scanValues {
+		scanValues[i] = reflect.New(types[i]).Interface()
+	}
+	return &goRows{g: g, rows: rows, columns: columns, columnTypes: goColumns(tt), scanValues: scanValues}, nil
+}
+
+func goColumns(tt []*sql.ColumnType) []*Column {
+	columns := make([]*Column, len(tt))
+	for i, columnType := range tt {
+		length, _ := columnType.Length()
+		columns[i] = &Column{
+			Name:   columnType.Name(),
+			Type:   typeCode(columnType.DatabaseTypeName()),
+			Length: int(length),
+		}
+	}
+	return columns
+}
+
+// Prepare prepares sql on the server, args are bound with taos_stmt_bind_param instead of being formatted into the sql.
+// The statement holds a native connection until closed, the native connections are capped by MaxOpen.
+//
+// The TDengine type of an arg follows its Go type, TDengine does not convert bound values to the column type on insert:
+//
+//	bool                       BOOL
+//	int8, int16, int32         TINYINT, SMALLINT, INT
+//	int, int64                 BIGINT
+//	uint8, uint16, uint32      TINYINT UNSIGNED, SMALLINT UNSIGNED, INT UNSIGNED
+//	uint64                     BIGINT UNSIGNED
+//	float32, float64           FLOAT, DOUBLE
+//	[]byte                     BINARY
+//	string                     NCHAR
+//	time.Time                  TIMESTAMP in the configured precision
+//	nil                        NULL
+//
+// so an INT column takes an int32 and a BINARY column a []byte, a driver.Valuer is bound as the value it returns.
+func (g *GoConnector) Prepare(ctx context.Context, sql string) (Stmt, error) {
+	stmt, err := g.native.prepare(ctx, sql, g.precision)
+	if err != nil {
+		return nil, err
+	}
+	return &goStmt{stmt: stmt}, nil
+}
+
+// ExecArgs prepares sql, binds args with native stmt binding as Prepare does and closes the statement.
+func (g *GoConnector) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	var affected int64
+	err := g.retrier.do(ctx, func() error {
+		stmt, err := g.native.prepare(ctx, sql, g.precision)
+		if err != nil {
+			return err
+		}
+		defer stmt.close()
+		affected, err = stmt.exec(ctx, args)
+		return err
+	})
+	return affected, err
+}
+
+// QueryArgs prepares sql, binds args with native stmt binding as Prepare does and closes the statement.
+func (g *GoConnector) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error) {
+	var result *Data
+	err := g.retrier.do(ctx, func() error {
+		stmt, err := g.native.prepare(ctx, sql, g.precision)
+		if err != nil {
+			return err
+		}
+		defer stmt.close()
+		result, err = stmt.query(ctx, args)
+		return err
+	})
+	return result, err
+}
+
+// SchemalessWrite writes lines with the native schemaless API.
+func (g *GoConnector) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	return g.retrier.do(ctx, func() error {
+		return g.native.schemalessWrite(ctx, db, protocol, lines, precision)
+	})
+}
+
+type goStmt struct {
+	stmt *nativeStmt
+}
+
+func (s *goStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	return s.stmt.exec(ctx, args)
+}
+
+func (s *goStmt) Query(ctx context.Context, args ...interface{}) (*Data, error) {
+	return s.stmt.query(ctx, args)
+}
+
+func (s *goStmt) Close() error {
+	return s.stmt.close()
+}
+
+func columnScanTypes(tt []*sql.ColumnType) []reflect.Type {
+	types := make([]reflect.Type, len(tt))
+	for i, columnType := range tt {
+		switch columnType.DatabaseTypeName() {
+		case "BOOL":
+			types[i] = nullBool
+		case "TINYINT":
+			types[i] = nullInt8
+		case "SMALLINT":
+			types[i] = nullInt16
+		case "INT":
+			types[i] = nullInt32
+		case "BIGINT":
+			types[i] = nullInt64
+		case "TINYINT UNSIGNED":
+			types[i] = nullUInt8
+		case "SMALLINT UNSIGNED":
+			types[i] = nullUInt16
+		case "INT UNSIGNED":
+			types[i] = nullUInt32
+		case "BIGINT UNSIGNED":
+			types[i] = nullUInt64
+		case "FLOAT":
+			types[i] = nullFloat32
+		case "DOUBLE":
+			types[i] = nullFloat64
+		case "BINARY", "VARCHAR", "NCHAR", "JSON":
+			types[i] = nullString
+		case "TIMESTAMP":
+			types[i] = nullTime
+		default:
+			// VARBINARY、GEOMETRY 以及驱动未命名的类型
+			types[i] = nullBytes
+		}
+	}
+	return types
+}
+
+// goRows reuses one set of scan destinations for every row so memory stays flat.
+type goRows struct {
+	g           *GoConnector
+	rows        *sql.Rows
+	columns     []string
+	columnTypes []*Column
+	scanValues  []interface{}
+	values      []interface{}
+	err         error
+}
+
+func (r *goRows) Columns() []string {
+	return r.columns
+}
+
+func (r *goRows) ColumnTypes() []*Column {
+	return r.columnTypes
+}
+
+func (r *goRows) Next() bool {
+	r.values = nil
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	if err := r.rows.Scan(r.scanValues...); err != nil {
+		r.err = r.g.changeError(err)
+		return false
+	}
+	values := make([]interface{}, len(r.scanValues))
+	for i, scanValue := range r.scanValues {
+		v, err := scanValue.(driver.Valuer).Value()
+		if err != nil {
+			r.err = err
+			return false
+		}
+		values[i] = v
+	}
+	r.values = values
+	return true
+}
+
+func (r *goRows) Scan(dest ...interface{}) error {
+	return scanRow(r.values, dest)
+}
+
+func (r *goRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.g.changeError(r.rows.Err())
+}
+
+func (r *goRows) Close() error {
+	r.values = nil
+	return r.rows.Close()
+}
+
 type info struct {
 	index  int
 	result [][]interface{}
@@ -205,3 +471,29 @@ func (g *GoConnector) changeError(err error) error {
 	}
 	return err
 }
+
+// Close closes the sql.DB pool and the idle native connections,
+// the native connection of a statement still open is closed with the statement.
+func (g *GoConnector) Close() error {
+	err := g.db.Close()
+	g.native.close()
+	return err
+}
+
+// Ping checks a connection of the sql.DB pool, opening one when none is idle.
+func (g *GoConnector) Ping(ctx context.Context) error {
+	return g.db.PingContext(ctx)
+}
+
+func (g *GoConnector) Health(ctx context.Context) (*common.Health, error) {
+	return checkHealth(ctx, g.query)
+}
+
+// Collector returns a Prometheus collector of the sql.DB connection pool stats, name is the db_name label.
+func (g *GoConnector) Collector(name string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(g.db, name)
+}
+
+func (g *GoConnector) connectorType() string {
+	return common.TDengineGoConnectorType
+}
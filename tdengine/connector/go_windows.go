@@ -0,0 +1,21 @@
+// +build windows
+
+package connector
+
+import (
+	"errors"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+// The go connector needs cgo and the TDengine client library, on windows it is only registered to return an explicit error.
+func init() {
+	MustRegister(&Registration{
+		Name:      common.TDengineGoConnectorType,
+		NewConfig: func() interface{} { return &config.TDengineGo{} },
+		New: func(conf interface{}) (TDengineConnector, error) {
+			return nil, errors.New("go connector is not supported on windows")
+		},
+		FromDSN: func(dsn *config.DSN) (interface{}, error) { return dsn.Go() },
+	})
+}
@@ -0,0 +1,81 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	taosErrors "github.com/taosdata/driver-go/errors"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"time"
+)
+
+const serverVersionSQL = "select server_version()"
+
+// HealthChecker is implemented by every connector of this package.
+// Ping checks that TDengine is reachable with the configured credentials,
+// Health also reports the server version and the round trip time, it is returned with the error when the check fails.
+// Neither retries, so they report the state of TDengine at the time of the call.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	Health(ctx context.Context) (*common.Health, error)
+}
+
+// checkHealth runs serverVersionSQL with query and measures the round trip.
+func checkHealth(ctx context.Context, query func(ctx context.Context, sql string) (*Data, error)) (*common.Health, error) {
+	start := time.Now()
+	data, err := query(ctx, serverVersionSQL)
+	health := &common.Health{RoundTrip: time.Since(start), Authenticated: true}
+	if err != nil {
+		health.Authenticated = !isAuthFailure(err)
+		return health, err
+	}
+	if len(data.Data) == 0 || len(data.Data[0]) == 0 {
+		return health, errors.New("no server version returned")
+	}
+	health.ServerVersion = fmt.Sprint(data.Data[0][0])
+	return health, nil
+}
+
+// isAuthFailure reports whether err is a rejected token, user or password, whatever the connector.
+func isAuthFailure(err error) bool {
+	if isAuthError(err) {
+		return true
+	}
+	var tdengineError *common.TDengineError
+	if errors.As(err, &tdengineError) {
+		switch int32(tdengineError.Code & 0xffff) {
+		case taosErrors.RPC_AUTH_REQUIRED, taosErrors.RPC_AUTH_FAILURE, taosErrors.MND_INVALID_USER, taosErrors.HTTP_INVALID_BASIC_AUTH:
+			return true
+		}
+	}
+	return false
+}
+
+func (h *RestfulConnector) Ping(ctx context.Context) error {
+	_, err := h.Health(ctx)
+	return err
+}
+
+func (h *RestfulConnector) Health(ctx context.Context) (*common.Health, error) {
+	return checkHealth(ctx, func(ctx context.Context, sql string) (*Data, error) {
+		var data *TDEngineRestfulResp
+		err := h.withAuth(ctx, func() error {
+			var err error
+			data, err = h.doQuery(ctx, sql)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Data{Head: data.Head, Data: data.Data, Columns: data.columns}, nil
+	})
+}
+
+func (w *WebSocketConnector) Ping(ctx context.Context) error {
+	_, err := w.Health(ctx)
+	return err
+}
+
+func (w *WebSocketConnector) Health(ctx context.Context) (*common.Health, error) {
+	return checkHealth(ctx, w.Query)
+}
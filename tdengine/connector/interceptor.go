@@ -0,0 +1,292 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"sync"
+)
+
+// Interceptor wraps a connector to add a cross-cutting concern such as logging, metrics, tracing or SQL rewriting.
+type Interceptor func(next TDengineConnector) TDengineConnector
+
+var (
+	interceptorsLock sync.RWMutex
+	interceptors     []Interceptor
+)
+
+// RegisterInterceptor adds interceptors applied by NewTDengineConnector to every connector it creates,
+// whatever the connector type. Interceptors registered first are the outermost.
+func RegisterInterceptor(interceptor ...Interceptor) {
+	interceptorsLock.Lock()
+	interceptors = append(interceptors, interceptor...)
+	interceptorsLock.Unlock()
+}
+
+func registeredInterceptors() []Interceptor {
+	interceptorsLock.RLock()
+	defer interceptorsLock.RUnlock()
+	return append([]Interceptor(nil), interceptors...)
+}
+
+// Chain wraps c with interceptors, the first one is the outermost and sees every call first.
+func Chain(c TDengineConnector, interceptors ...Interceptor) TDengineConnector {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		c = interceptors[i](c)
+	}
+	return c
+}
+
+// ConnectorType returns the type of c such as common.TDengineRestfulConnectorType,
+// looking through the connectors that wrap another one with an Unwrap method. It returns the Go type for other connectors.
+func ConnectorType(c TDengineConnector) string {
+	for {
+		switch t := c.(type) {
+		case interface{ connectorType() string }:
+			return t.connectorType()
+		case interface{ Unwrap() TDengineConnector }:
+			c = t.Unwrap()
+		default:
+			return fmt.Sprintf("%T", c)
+		}
+	}
+}
+
+// Call describes a connector call seen by a CallHandler.
+// Method is Exec, Query, QueryRows, Prepare, ExecArgs, QueryArgs, Stmt.Exec, Stmt.Query or SchemalessWrite.
+// For SchemalessWrite SQL is the protocol and Lines the written lines.
+// Affected and Data are set by invoke once the call returns.
+type Call struct {
+	Method   string
+	SQL      string
+	Args     []interface{}
+	Lines    []string
+	Affected int64
+	Data     *Data
+}
+
+// CallHandler runs around every call of an intercepted connector, it must call invoke to reach the next connector.
+// The SQL and Args of call may be changed before invoke, the context passed to invoke is the one the next connector sees.
+type CallHandler func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error
+
+// Intercept returns an Interceptor running handler around every call,
+// it is the easiest way to write an interceptor that does not need to override single methods.
+// The returned connector implements SchemalessWriter and HealthChecker only when next does.
+func Intercept(handler CallHandler) Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		return withCapabilities(&callInterceptor{next: next, handler: handler})
+	}
+}
+
+// interceptedConnector is a connector built on callInterceptor, whatever next supports.
+type interceptedConnector interface {
+	TDengineConnector
+	Unwrap() TDengineConnector
+	schemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error
+	ping(ctx context.Context) error
+	health(ctx context.Context) (*common.Health, error)
+}
+
+// withCapabilities returns c with the optional interfaces of the connector it wraps,
+// so a type assertion on c succeeds exactly when it does on the wrapped connector.
+func withCapabilities(c interceptedConnector) TDengineConnector {
+	_, writer := c.Unwrap().(SchemalessWriter)
+	_, checker := c.Unwrap().(HealthChecker)
+	switch {
+	case writer && checker:
+		return &schemalessHealthConnector{c}
+	case writer:
+		return &schemalessConnector{c}
+	case checker:
+		return &healthConnector{c}
+	}
+	return c
+}
+
+type schemalessConnector struct {
+	interceptedConnector
+}
+
+func (c *schemalessConnector) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	return c.schemalessWrite(ctx, db, protocol, lines, precision)
+}
+
+type healthConnector struct {
+	interceptedConnector
+}
+
+func (c *healthConnector) Ping(ctx context.Context) error {
+	return c.ping(ctx)
+}
+
+func (c *healthConnector) Health(ctx context.Context) (*common.Health, error) {
+	return c.health(ctx)
+}
+
+type schemalessHealthConnector struct {
+	interceptedConnector
+}
+
+func (c *schemalessHealthConnector) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	return c.schemalessWrite(ctx, db, protocol, lines, precision)
+}
+
+func (c *schemalessHealthConnector) Ping(ctx context.Context) error {
+	return c.ping(ctx)
+}
+
+func (c *schemalessHealthConnector) Health(ctx context.Context) (*common.Health, error) {
+	return c.health(ctx)
+}
+
+type callInterceptor struct {
+	next    TDengineConnector
+	handler CallHandler
+}
+
+// Unwrap returns the intercepted connector.
+func (c *callInterceptor) Unwrap() TDengineConnector {
+	return c.next
+}
+
+func (c *callInterceptor) Exec(ctx context.Context, sql string) (int64, error) {
+	call := &Call{Method: "Exec", SQL: sql}
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Affected, err = c.next.Exec(ctx, call.SQL)
+		return err
+	})
+	return call.Affected, err
+}
+
+func (c *callInterceptor) Query(ctx context.Context, sql string) (*Data, error) {
+	call := &Call{Method: "Query", SQL: sql}
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Data, err = c.next.Query(ctx, call.SQL)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Data, nil
+}
+
+func (c *callInterceptor) QueryRows(ctx context.Context, sql string) (Rows, error) {
+	call := &Call{Method: "QueryRows", SQL: sql}
+	var rows Rows
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		rows, err = c.next.QueryRows(ctx, call.SQL)
+		return err
+	})
+	if err != nil {
+		if rows != nil {
+			rows.Close()
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (c *callInterceptor) Prepare(ctx context.Context, sql string) (Stmt, error) {
+	call := &Call{Method: "Prepare", SQL: sql}
+	var stmt Stmt
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		stmt, err = c.next.Prepare(ctx, call.SQL)
+		return err
+	})
+	if err != nil {
+		if stmt != nil {
+			stmt.Close()
+		}
+		return nil, err
+	}
+	return &interceptedStmt{stmt: stmt, sql: call.SQL, handler: c.handler}, nil
+}
+
+func (c *callInterceptor) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	call := &Call{Method: "ExecArgs", SQL: sql, Args: args}
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Affected, err = c.next.ExecArgs(ctx, call.SQL, call.Args...)
+		return err
+	})
+	return call.Affected, err
+}
+
+func (c *callInterceptor) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error) {
+	call := &Call{Method: "QueryArgs", SQL: sql, Args: args}
+	err := c.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Data, err = c.next.QueryArgs(ctx, call.SQL, call.Args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Data, nil
+}
+
+func (c *callInterceptor) schemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	writer := c.next.(SchemalessWriter)
+	call := &Call{Method: "SchemalessWrite", SQL: protocol, Lines: lines}
+	return c.handler(ctx, call, func(ctx context.Context) error {
+		return writer.SchemalessWrite(ctx, db, call.SQL, call.Lines, precision)
+	})
+}
+
+// ping and health are not intercepted, they go straight to the next connector.
+func (c *callInterceptor) ping(ctx context.Context) error {
+	return c.next.(HealthChecker).Ping(ctx)
+}
+
+func (c *callInterceptor) health(ctx context.Context) (*common.Health, error) {
+	return c.next.(HealthChecker).Health(ctx)
+}
+
+type interceptedStmt struct {
+	stmt    Stmt
+	sql     string
+	handler CallHandler
+}
+
+func (s *interceptedStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	call := &Call{Method: "Stmt.Exec", SQL: s.sql, Args: args}
+	err := s.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Affected, err = s.stmt.Exec(ctx, call.Args...)
+		return err
+	})
+	return call.Affected, err
+}
+
+func (s *interceptedStmt) Query(ctx context.Context, args ...interface{}) (*Data, error) {
+	call := &Call{Method: "Stmt.Query", SQL: s.sql, Args: args}
+	err := s.handler(ctx, call, func(ctx context.Context) (err error) {
+		call.Data, err = s.stmt.Query(ctx, call.Args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Data, nil
+}
+
+func (s *interceptedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// Logger is the logger used by LogInterceptor, logrus loggers implement it.
+type Logger interface {
+	Info(args ...interface{})
+}
+
+// LogInterceptor logs the SQL of every call before it is sent, with its args when there are some.
+func LogInterceptor(logger Logger) Interceptor {
+	return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		switch {
+		case call.Lines != nil:
+			logger.Info(call.SQL, call.Lines)
+		case call.Args != nil:
+			logger.Info(call.SQL, call.Args)
+		default:
+			logger.Info(call.SQL)
+		}
+		return invoke(ctx)
+	})
+}
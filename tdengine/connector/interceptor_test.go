@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+// sqlConnector records the SQL it receives and supports schemaless writes and health checks.
+type sqlConnector struct {
+	rowsConnector
+	sqls []string
+}
+
+func (c *sqlConnector) Exec(ctx context.Context, sql string) (int64, error) {
+	c.sqls = append(c.sqls, sql)
+	return 1, nil
+}
+
+func (c *sqlConnector) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	c.sqls = append(c.sqls, protocol)
+	return nil
+}
+
+func (c *sqlConnector) Ping(ctx context.Context) error { return nil }
+
+func (c *sqlConnector) Health(ctx context.Context) (*common.Health, error) {
+	return &common.Health{}, nil
+}
+
+func TestChainOrder(t *testing.T) {
+	var events []string
+	named := func(name string) Interceptor {
+		return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			events = append(events, name+" before")
+			call.SQL += " /* " + name + " */"
+			err := invoke(ctx)
+			events = append(events, name+" after")
+			return err
+		})
+	}
+	next := &sqlConnector{}
+	c := Chain(next, named("outer"), named("inner"))
+	affected, err := c.Exec(context.Background(), "insert")
+	if err != nil || affected != 1 {
+		t.Fatalf("got %d, %v", affected, err)
+	}
+	if want := []string{"outer before", "inner before", "inner after", "outer after"}; !reflect.DeepEqual(events, want) {
+		t.Fatalf("got %v, want %v", events, want)
+	}
+	if want := []string{"insert /* outer */ /* inner */"}; !reflect.DeepEqual(next.sqls, want) {
+		t.Fatalf("next got %v, want %v", next.sqls, want)
+	}
+	if got := ConnectorType(c); got != "*connector.sqlConnector" {
+		t.Fatalf("got connector type %s", got)
+	}
+}
+
+func TestInterceptCapabilities(t *testing.T) {
+	pass := Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		return invoke(ctx)
+	})
+	limiter := NewLimiter(&config.Limit{}).Interceptor()
+	for name, interceptor := range map[string]Interceptor{"Intercept": pass, "Limiter": limiter} {
+		t.Run(name, func(t *testing.T) {
+			c := interceptor(rowsConnector{})
+			if _, ok := c.(SchemalessWriter); ok {
+				t.Fatal("wrapped rowsConnector is a SchemalessWriter")
+			}
+			if _, ok := c.(HealthChecker); ok {
+				t.Fatal("wrapped rowsConnector is a HealthChecker")
+			}
+
+			next := &sqlConnector{}
+			c = Chain(next, interceptor, pass)
+			writer, ok := c.(SchemalessWriter)
+			if !ok {
+				t.Fatal("wrapped sqlConnector is not a SchemalessWriter")
+			}
+			if err := writer.SchemalessWrite(context.Background(), "db", "influxdb", []string{"m v=1"}, "ms"); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(next.sqls, []string{"influxdb"}) {
+				t.Fatalf("next got %v", next.sqls)
+			}
+			checker, ok := c.(HealthChecker)
+			if !ok {
+				t.Fatal("wrapped sqlConnector is not a HealthChecker")
+			}
+			if err := checker.Ping(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
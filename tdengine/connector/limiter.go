@@ -0,0 +1,262 @@
+package connector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/taosdata/go-utils/tdengine/config"
+	"github.com/taosdata/go-utils/tdengine/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+var limiterWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "tdengine_limiter_wait_seconds",
+	Help:    "Time calls waited for the client side limits of a connector type before being sent.",
+	Buckets: []float64{.0001, .001, .005, .01, .05, .1, .5, 1, 5, 10},
+}, []string{"connector"})
+
+// Once there are maxKeyLimiters key limits, the ones without calls for keyLimiterIdle are dropped.
+const (
+	maxKeyLimiters = 1024
+	keyLimiterIdle = time.Minute
+)
+
+type limitKey struct{}
+
+// WithLimitKey returns a context whose calls are also limited by the key limits, such as the ones of a tenant.
+func WithLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, limitKey{}, key)
+}
+
+func limitKeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(limitKey{}).(string)
+	return key, ok
+}
+
+// limit is an in-flight semaphore and a token bucket, a nil field does not limit.
+type limit struct {
+	inFlight chan struct{}
+	rate     *rate.Limiter
+	lastUsed time.Time
+	used     int
+}
+
+func newLimit(maxInFlight int, qps float64, burst int) *limit {
+	l := &limit{}
+	if maxInFlight > 0 {
+		l.inFlight = make(chan struct{}, maxInFlight)
+	}
+	if qps > 0 {
+		if burst <= 0 {
+			burst = int(qps)
+			if burst < 1 {
+				burst = 1
+			}
+		}
+		l.rate = rate.NewLimiter(rate.Limit(qps), burst)
+	}
+	return l
+}
+
+// acquireSlot waits for an in-flight slot.
+func (l *limit) acquireSlot(ctx context.Context) error {
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (l *limit) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// Limiter caps the concurrent calls and the calls per second of a connector, waiting calls give up with their context.
+type Limiter struct {
+	conf   config.Limit
+	global *limit
+
+	lock sync.Mutex
+	keys map[string]*limit
+}
+
+func NewLimiter(conf *config.Limit) *Limiter {
+	registerMetrics()
+	return &Limiter{
+		conf:   *conf,
+		global: newLimit(conf.MaxInFlight, conf.QPS, conf.Burst),
+		keys:   map[string]*limit{},
+	}
+}
+
+// LimiterInterceptor wraps every connector in its own Limiter, register it with RegisterInterceptor to limit every connector.
+func LimiterInterceptor(conf *config.Limit) Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		return NewLimiter(conf).Interceptor()(next)
+	}
+}
+
+// Interceptor limits the calls to next, the time spent waiting is recorded in the
+// tdengine_limiter_wait_seconds histogram and as tdengine.queue_time on the current span.
+// QueryRows holds its slot until the rows are closed, since the rows keep reading from TDengine.
+func (l *Limiter) Interceptor() Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		name := ConnectorType(next)
+		handler := func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			release, err := l.wait(ctx, name)
+			if err != nil {
+				return err
+			}
+			defer release()
+			return invoke(ctx)
+		}
+		return withCapabilities(&limitedConnector{callInterceptor: &callInterceptor{next: next, handler: handler}, limiter: l, name: name})
+	}
+}
+
+type limitedConnector struct {
+	*callInterceptor
+	limiter *Limiter
+	name    string
+}
+
+func (c *limitedConnector) QueryRows(ctx context.Context, sql string) (Rows, error) {
+	release, err := c.limiter.wait(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.next.QueryRows(ctx, sql)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedRows{Rows: rows, release: release}, nil
+}
+
+// limitedRows releases the limiter slot when closed.
+type limitedRows struct {
+	Rows
+	release func()
+	once    sync.Once
+}
+
+func (r *limitedRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// Wait blocks until a call is allowed by the key limits, when ctx has a key, and by the connector limits.
+// release must be called once the call is done. The waits are recorded without connector type,
+// the calls through Interceptor are recorded under the type of the wrapped connector.
+func (l *Limiter) Wait(ctx context.Context) (release func(), err error) {
+	return l.wait(ctx, "")
+}
+
+// wait takes the in-flight slots first and only then the rate tokens,
+// so a call giving up while waiting for a slot does not use up the rate, and the reserved tokens are given back on cancel.
+func (l *Limiter) wait(ctx context.Context, name string) (release func(), err error) {
+	start := time.Now()
+	defer func() {
+		queued := time.Since(start)
+		limiterWait.WithLabelValues(name).Observe(queued.Seconds())
+		trace.SpanFromContext(ctx).SetAttributes(tracing.QueueTimeKey.Int64(queued.Microseconds()))
+	}()
+	limits := []*limit{l.global}
+	keyLimit := l.keyLimit(ctx)
+	if keyLimit != nil {
+		limits = []*limit{keyLimit, l.global}
+	}
+	acquired := 0
+	release = func() {
+		for _, lim := range limits[:acquired] {
+			lim.release()
+		}
+		if keyLimit != nil {
+			l.releaseKey(keyLimit)
+		}
+	}
+	for _, lim := range limits {
+		if err = lim.acquireSlot(ctx); err != nil {
+			release()
+			return nil, err
+		}
+		acquired++
+	}
+	now := time.Now()
+	var delay time.Duration
+	var reservations []*rate.Reservation
+	for _, lim := range limits {
+		if lim.rate == nil {
+			continue
+		}
+		r := lim.rate.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			now = time.Now()
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// keyLimit returns the limit of the key of ctx, nil without key or key limits.
+func (l *Limiter) keyLimit(ctx context.Context) *limit {
+	if l.conf.KeyMaxInFlight <= 0 && l.conf.KeyQPS <= 0 {
+		return nil
+	}
+	key, ok := limitKeyFrom(ctx)
+	if !ok {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	keyLimit, exist := l.keys[key]
+	if !exist {
+		if len(l.keys) >= maxKeyLimiters {
+			l.evictIdle()
+		}
+		keyLimit = newLimit(l.conf.KeyMaxInFlight, l.conf.KeyQPS, l.conf.KeyBurst)
+		l.keys[key] = keyLimit
+	}
+	keyLimit.used++
+	return keyLimit
+}
+
+// releaseKey records that a call of keyLimit is done, its slot is released by the caller.
+func (l *Limiter) releaseKey(keyLimit *limit) {
+	l.lock.Lock()
+	keyLimit.used--
+	keyLimit.lastUsed = time.Now()
+	l.lock.Unlock()
+}
+
+// evictIdle drops the key limits unused for keyLimiterIdle, it is called with the lock held.
+func (l *Limiter) evictIdle() {
+	now := time.Now()
+	for key, keyLimit := range l.keys {
+		if keyLimit.used == 0 && now.Sub(keyLimit.lastUsed) >= keyLimiterIdle {
+			delete(l.keys, key)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+// rowsConnector answers every query with an empty result.
+type rowsConnector struct{}
+
+func (rowsConnector) Exec(ctx context.Context, sql string) (int64, error) { return 0, nil }
+
+func (rowsConnector) Query(ctx context.Context, sql string) (*Data, error) { return &Data{}, nil }
+
+func (rowsConnector) QueryRows(ctx context.Context, sql string) (Rows, error) {
+	return NewDataRows(&Data{}), nil
+}
+
+func (rowsConnector) Prepare(ctx context.Context, sql string) (Stmt, error) { return nil, nil }
+
+func (rowsConnector) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return 0, nil
+}
+
+func (rowsConnector) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error) {
+	return &Data{}, nil
+}
+
+func TestLimiterQueryRows(t *testing.T) {
+	c := Chain(rowsConnector{}, NewLimiter(&config.Limit{MaxInFlight: 1}).Interceptor())
+	rows, err := c.QueryRows(context.Background(), "select * from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = c.Query(ctx, "select * from t"); err == nil {
+		t.Fatal("Query got a slot while the rows were open")
+	}
+	if err = rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if _, err = c.Query(context.Background(), "select * from t"); err != nil {
+		t.Fatalf("Query after closing the rows returned %v", err)
+	}
+	if _, err = c.Query(context.Background(), "select * from t"); err != nil {
+		t.Fatalf("Query returned %v, closing the rows twice must release once", err)
+	}
+}
+
+func TestLimiterKeepsTokensOfCanceledCalls(t *testing.T) {
+	c := Chain(rowsConnector{}, NewLimiter(&config.Limit{MaxInFlight: 1, QPS: 0.01, Burst: 2}).Interceptor())
+	rows, err := c.QueryRows(context.Background(), "select * from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = c.Query(ctx, "select * from t"); err == nil {
+		t.Fatal("Query got a slot while the rows were open")
+	}
+	rows.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = c.Query(ctx, "select * from t"); err != nil {
+		t.Fatalf("Query returned %v, the canceled call must not have used the second token", err)
+	}
+}
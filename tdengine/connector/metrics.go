@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tdengine_connector_request_duration_seconds",
+		Help:    "Latency of the calls to TDengine by connector type, method, statement kind and error code.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"connector", "method", "kind", "code"})
+	requestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tdengine_connector_in_flight_requests",
+		Help: "Calls to TDengine waiting for a response by connector type.",
+	}, []string{"connector"})
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the connector metrics in the default Prometheus registry once, when the first
+// MetricsInterceptor, CircuitBreaker or Limiter is created, importing the package registers nothing.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(requestDuration, requestsInFlight, breakerState, breakerTransitions, breakerRejected, limiterWait)
+	})
+}
+
+// statementKinds bounds the kind label to the common statements, others are counted as other.
+var statementKinds = map[string]bool{
+	"select":   true,
+	"insert":   true,
+	"create":   true,
+	"drop":     true,
+	"alter":    true,
+	"describe": true,
+	"show":     true,
+	"use":      true,
+	"delete":   true,
+}
+
+// statementKind returns the lower case first keyword of sql.
+func statementKind(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexAny(sql, " \t\r\n("); i >= 0 {
+		sql = sql[:i]
+	}
+	kind := strings.ToLower(sql)
+	if statementKinds[kind] {
+		return kind
+	}
+	return "other"
+}
+
+// errorCode returns the code label of err: empty on success, the TDengine error code in hex,
+// http_ followed by the status for unexpected HTTP status, canceled, deadline_exceeded or error.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var tdengineError *common.TDengineError
+	if errors.As(err, &tdengineError) {
+		return fmt.Sprintf("0x%04x", tdengineError.Code&0xffff)
+	}
+	var statusError *common.HTTPStatusError
+	if errors.As(err, &statusError) {
+		return fmt.Sprintf("http_%d", statusError.StatusCode)
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	}
+	return "error"
+}
+
+// MetricsInterceptor records the latency and the in-flight count of every call in the default Prometheus registry,
+// register it with RegisterInterceptor(MetricsInterceptor()) to measure every connector.
+// The metrics are registered on the first call, nothing is registered by importing the package.
+func MetricsInterceptor() Interceptor {
+	registerMetrics()
+	return func(next TDengineConnector) TDengineConnector {
+		connectorType := ConnectorType(next)
+		inFlight := requestsInFlight.WithLabelValues(connectorType)
+		return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			kind := "schemaless"
+			if call.Lines == nil {
+				kind = statementKind(call.SQL)
+			}
+			inFlight.Inc()
+			start := time.Now()
+			err := invoke(ctx)
+			requestDuration.WithLabelValues(connectorType, call.Method, kind, errorCode(err)).Observe(time.Since(start).Seconds())
+			inFlight.Dec()
+			return err
+		})(next)
+	}
+}
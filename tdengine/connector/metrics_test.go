@@ -0,0 +1,140 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+func TestStatementKind(t *testing.T) {
+	tests := map[string]string{
+		"select * from t":          "select",
+		"  INSERT into t values()": "insert",
+		"describe\tst":             "describe",
+		"show(databases)":          "show",
+		"explain select 1":         "other",
+		"":                         "other",
+	}
+	for sql, want := range tests {
+		if got := statementKind(sql); got != want {
+			t.Fatalf("%q got %s, want %s", sql, got, want)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&common.TDengineError{Code: 0x2662}, "0x2662"},
+		{fmt.Errorf("query: %w", &common.TDengineError{Code: -2147473822}), "0x2662"},
+		{&common.HTTPStatusError{StatusCode: http.StatusBadGateway}, "http_502"},
+		{context.Canceled, "canceled"},
+		{fmt.Errorf("wait: %w", context.DeadlineExceeded), "deadline_exceeded"},
+		{errors.New("other"), "error"},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.want {
+			t.Fatalf("%v got %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := requestDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	var inFlight float64
+	check := Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		inFlight = testutil.ToFloat64(requestsInFlight.WithLabelValues(ConnectorType(&sqlConnector{})))
+		return invoke(ctx)
+	})
+	c := Chain(&sqlConnector{}, MetricsInterceptor(), check)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Exec(context.Background(), "insert into t values(now, 1)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inFlight != 1 {
+		t.Fatalf("got %v in flight during the call, want 1", inFlight)
+	}
+	if got := testutil.ToFloat64(requestsInFlight.WithLabelValues("*connector.sqlConnector")); got != 0 {
+		t.Fatalf("got %v in flight after the calls, want 0", got)
+	}
+	if got := sampleCount(t, "*connector.sqlConnector", "Exec", "insert", ""); got != 2 {
+		t.Fatalf("got %d observations, want 2", got)
+	}
+	if _, err := Chain(failingConnector{}, MetricsInterceptor()).Exec(context.Background(), "drop table t"); err == nil {
+		t.Fatal("Exec succeeded")
+	}
+	if got := sampleCount(t, "connector.failingConnector", "Exec", "drop", "0x2662"); got != 1 {
+		t.Fatalf("got %d failed observations, want 1", got)
+	}
+}
+
+func TestRestfulCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"column_meta":[["affected_rows",4,4]],"data":[[1]],"rows":1,"status":"succ"}`))
+	}))
+	defer server.Close()
+	conf := &config.TDengineRestful{Address: server.URL}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewRestfulConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 0; i < 2; i++ {
+		if _, err = c.Exec(context.Background(), "insert into t values(now, 1)"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c.Collector("test"))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reused" {
+					key += "_reused_" + label.GetValue()
+				}
+			}
+			if metric.GetGauge() != nil {
+				values[key] = metric.GetGauge().GetValue()
+			} else {
+				values[key] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	if values["tdengine_restful_endpoint_healthy"] != 1 || values["tdengine_restful_endpoint_in_flight_requests"] != 0 {
+		t.Fatalf("got endpoint metrics %v, want a healthy endpoint without requests in flight", values)
+	}
+	created, reused := values["tdengine_restful_connections_total_reused_false"], values["tdengine_restful_connections_total_reused_true"]
+	if created < 1 || created+reused != 2 {
+		t.Fatalf("got %v created and %v reused connections, want 2 in total", created, reused)
+	}
+}
@@ -0,0 +1,634 @@
+// +build !windows
+
+package connector
+
+/*
+#cgo CFLAGS: -I/usr/include
+#cgo linux LDFLAGS: -L/usr/lib -ltaos
+#cgo darwin LDFLAGS: -L/usr/local/taos/driver -ltaos
+#include <stdlib.h>
+#include <string.h>
+#include <taos.h>
+#include <taoserror.h>
+*/
+import "C"
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// taosSqlDSN is a parsed taosSql DSN: [user[:password]@][net[(addr:port)]]/dbname[?param1=value1&paramN=valueN]
+type taosSqlDSN struct {
+	user     string
+	password string
+	host     string
+	port     int
+	db       string
+}
+
+func parseTaosSqlDSN(dsn string) (*taosSqlDSN, error) {
+	result := &taosSqlDSN{user: "root", password: "taosdata"}
+	slash := strings.LastIndexByte(dsn, '/')
+	if slash < 0 {
+		return nil, errors.New("invalid DSN: missing the slash separating the database name")
+	}
+	left := dsn[:slash]
+	if at := strings.LastIndexByte(left, '@'); at >= 0 {
+		userInfo := left[:at]
+		left = left[at+1:]
+		if colon := strings.IndexByte(userInfo, ':'); colon >= 0 {
+			result.user = userInfo[:colon]
+			result.password = userInfo[colon+1:]
+		} else {
+			result.user = userInfo
+		}
+	}
+	if open := strings.IndexByte(left, '('); open >= 0 {
+		if !strings.HasSuffix(left, ")") {
+			return nil, errors.New("invalid DSN: network address not terminated (missing closing brace)")
+		}
+		address := left[open+1 : len(left)-1]
+		colon := strings.LastIndexByte(address, ':')
+		if colon < 0 {
+			return nil, errors.New("invalid DSN: network address must be host:port")
+		}
+		if colon > 0 {
+			result.host = address[:colon]
+			port, err := strconv.Atoi(address[colon+1:])
+			if err != nil {
+				return nil, errors.New("invalid DSN: network port is not a valid number")
+			}
+			result.port = port
+		}
+	}
+	result.db = dsn[slash+1:]
+	if question := strings.IndexByte(result.db, '?'); question >= 0 {
+		result.db = result.db[:question]
+	}
+	return result, nil
+}
+
+// nativeConn is a connection of the TDengine client library,
+// it is used for what database/sql cannot express such as server side parameter binding.
+type nativeConn struct {
+	taos unsafe.Pointer
+}
+
+func nativeConnect(dsn *taosSqlDSN) (*nativeConn, error) {
+	var host, db *C.char
+	if dsn.host != "" {
+		host = C.CString(dsn.host)
+		defer C.free(unsafe.Pointer(host))
+	}
+	if dsn.db != "" {
+		db = C.CString(dsn.db)
+		defer C.free(unsafe.Pointer(db))
+	}
+	user := C.CString(dsn.user)
+	defer C.free(unsafe.Pointer(user))
+	password := C.CString(dsn.password)
+	defer C.free(unsafe.Pointer(password))
+	taos := C.taos_connect(host, user, password, db, C.ushort(dsn.port))
+	if taos == nil {
+		return nil, nativeResultError(nil)
+	}
+	return &nativeConn{taos: taos}, nil
+}
+
+func (c *nativeConn) close() {
+	C.taos_close(c.taos)
+	c.taos = nil
+}
+
+// nativePool keeps idle native connections, connections are opened on demand.
+// Like the sql.DB pool, at most maxOpen connections are open, idle ones included, 0 means unlimited.
+type nativePool struct {
+	dsn  *taosSqlDSN
+	idle chan *nativeConn
+	// open 中的每个元素对应一个已打开的连接，为 nil 时不限制
+	open   chan struct{}
+	closed int32
+}
+
+func newNativePool(dsn *taosSqlDSN, maxIdle int, maxOpen int) *nativePool {
+	if maxIdle < 0 {
+		maxIdle = 0
+	}
+	p := &nativePool{dsn: dsn, idle: make(chan *nativeConn, maxIdle)}
+	if maxOpen > 0 {
+		p.open = make(chan struct{}, maxOpen)
+	}
+	return p
+}
+
+// get returns an idle connection or opens one, it waits for a connection to be put back when maxOpen are open.
+func (p *nativePool) get(ctx context.Context) (*nativeConn, error) {
+	if atomic.LoadInt32(&p.closed) != 0 {
+		return nil, errConnectorClosed
+	}
+	select {
+	case conn := <-p.idle:
+		return conn, nil
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.open != nil {
+		select {
+		case conn := <-p.idle:
+			return conn, nil
+		case p.open <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	conn, err := nativeConnect(p.dsn)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *nativePool) put(conn *nativeConn) {
+	if atomic.LoadInt32(&p.closed) != 0 {
+		p.discard(conn)
+		return
+	}
+	select {
+	case p.idle <- conn:
+	default:
+		p.discard(conn)
+	}
+	// close 可能在放回前清空了空闲连接
+	if atomic.LoadInt32(&p.closed) != 0 {
+		p.closeIdle()
+	}
+}
+
+// close closes the idle connections, the ones in use are closed when put back.
+func (p *nativePool) close() {
+	if atomic.CompareAndSwapInt32(&p.closed, 0, 1) {
+		p.closeIdle()
+	}
+}
+
+func (p *nativePool) closeIdle() {
+	for {
+		select {
+		case conn := <-p.idle:
+			p.discard(conn)
+		default:
+			return
+		}
+	}
+}
+
+// discard closes conn instead of putting it back, for connections left in an unknown state.
+func (p *nativePool) discard(conn *nativeConn) {
+	conn.close()
+	p.release()
+}
+
+func (p *nativePool) release() {
+	if p.open != nil {
+		<-p.open
+	}
+}
+
+// nativeStmt is a prepared statement bound with taos_stmt_bind_param, it may be used by one goroutine at a time.
+type nativeStmt struct {
+	lock      sync.Mutex
+	pool      *nativePool
+	conn      *nativeConn
+	stmt      unsafe.Pointer
+	precision time.Duration
+}
+
+func (p *nativePool) prepare(ctx context.Context, sql string, precision time.Duration) (*nativeStmt, error) {
+	conn, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stmt := C.taos_stmt_init(conn.taos)
+	if stmt == nil {
+		p.put(conn)
+		return nil, nativeResultError(nil)
+	}
+	csql := C.CString(sql)
+	defer C.free(unsafe.Pointer(csql))
+	if code := C.taos_stmt_prepare(stmt, csql, C.ulong(len(sql))); code != 0 {
+		C.taos_stmt_close(stmt)
+		p.put(conn)
+		return nil, nativeCodeError(code)
+	}
+	return &nativeStmt{pool: p, conn: conn, stmt: stmt, precision: precision}, nil
+}
+
+func (s *nativeStmt) exec(ctx context.Context, args []interface{}) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res, err := s.execute(ctx, args)
+	if err != nil {
+		return 0, err
+	}
+	defer C.taos_free_result(res)
+	return int64(C.taos_affected_rows(res)), nil
+}
+
+func (s *nativeStmt) query(ctx context.Context, args []interface{}) (*Data, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res, err := s.execute(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer C.taos_free_result(res)
+	return readNativeResult(ctx, res)
+}
+
+// execute binds args and runs the statement, the caller frees the result with taos_free_result.
+// The C calls cannot be interrupted, ctx is checked between them.
+func (s *nativeStmt) execute(ctx context.Context, args []interface{}) (unsafe.Pointer, error) {
+	if s.stmt == nil {
+		return nil, errStmtClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	binds, free, err := s.bind(args)
+	defer free()
+	if err != nil {
+		return nil, err
+	}
+	var bindsPointer *C.TAOS_BIND
+	if len(binds) > 0 {
+		bindsPointer = &binds[0]
+	}
+	if code := C.taos_stmt_bind_param(s.stmt, bindsPointer); code != 0 {
+		return nil, nativeCodeError(code)
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	var isInsert C.int
+	if code := C.taos_stmt_is_insert(s.stmt, &isInsert); code != 0 {
+		return nil, nativeCodeError(code)
+	}
+	if isInsert == 1 {
+		if code := C.taos_stmt_add_batch(s.stmt); code != 0 {
+			return nil, nativeCodeError(code)
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if code := C.taos_stmt_execute(s.stmt); code != 0 {
+		return nil, nativeCodeError(code)
+	}
+	res := C.taos_stmt_use_result(s.stmt)
+	if res == nil {
+		return nil, nativeResultError(nil)
+	}
+	if C.taos_errno(res) != 0 {
+		err = nativeResultError(res)
+		C.taos_free_result(res)
+		return nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		C.taos_free_result(res)
+		return nil, err
+	}
+	return res, nil
+}
+
+// bind converts args to TAOS_BIND, the memory is allocated by C and released by free.
+// Go types map to TDengine types as follows: bool BOOL, int8 TINYINT, int16 SMALLINT, int32 INT, int and int64 BIGINT,
+// the unsigned integers to their unsigned counterparts, float32 FLOAT, float64 DOUBLE, []byte BINARY, string NCHAR and
+// time.Time TIMESTAMP in the configured precision.
+func (s *nativeStmt) bind(args []interface{}) (binds []C.TAOS_BIND, free func(), err error) {
+	var allocated []unsafe.Pointer
+	alloc := func(size int) unsafe.Pointer {
+		p := C.calloc(1, C.size_t(size))
+		allocated = append(allocated, p)
+		return p
+	}
+	free = func() {
+		for _, p := range allocated {
+			C.free(p)
+		}
+	}
+	binds = make([]C.TAOS_BIND, len(args))
+	for i, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			if arg, err = valuer.Value(); err != nil {
+				return nil, free, err
+			}
+		}
+		bind := &binds[i]
+		switch v := arg.(type) {
+		case nil:
+			bind.buffer_type = C.TSDB_DATA_TYPE_NULL
+			isNull := (*C.int)(alloc(C.sizeof_int))
+			*isNull = 1
+			bind.is_null = isNull
+		case bool:
+			bind.buffer_type = C.TSDB_DATA_TYPE_BOOL
+			p := (*C.int8_t)(alloc(1))
+			if v {
+				*p = 1
+			}
+			bind.buffer = unsafe.Pointer(p)
+		case int8:
+			bind.buffer_type = C.TSDB_DATA_TYPE_TINYINT
+			p := (*C.int8_t)(alloc(1))
+			*p = C.int8_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case int16:
+			bind.buffer_type = C.TSDB_DATA_TYPE_SMALLINT
+			p := (*C.int16_t)(alloc(2))
+			*p = C.int16_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case int32:
+			bind.buffer_type = C.TSDB_DATA_TYPE_INT
+			p := (*C.int32_t)(alloc(4))
+			*p = C.int32_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case int:
+			bind.buffer_type = C.TSDB_DATA_TYPE_BIGINT
+			p := (*C.int64_t)(alloc(8))
+			*p = C.int64_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case int64:
+			bind.buffer_type = C.TSDB_DATA_TYPE_BIGINT
+			p := (*C.int64_t)(alloc(8))
+			*p = C.int64_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case uint8:
+			bind.buffer_type = C.TSDB_DATA_TYPE_UTINYINT
+			p := (*C.uint8_t)(alloc(1))
+			*p = C.uint8_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case uint16:
+			bind.buffer_type = C.TSDB_DATA_TYPE_USMALLINT
+			p := (*C.uint16_t)(alloc(2))
+			*p = C.uint16_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case uint32:
+			bind.buffer_type = C.TSDB_DATA_TYPE_UINT
+			p := (*C.uint32_t)(alloc(4))
+			*p = C.uint32_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case uint64:
+			bind.buffer_type = C.TSDB_DATA_TYPE_UBIGINT
+			p := (*C.uint64_t)(alloc(8))
+			*p = C.uint64_t(v)
+			bind.buffer = unsafe.Pointer(p)
+		case float32:
+			bind.buffer_type = C.TSDB_DATA_TYPE_FLOAT
+			p := (*C.float)(alloc(4))
+			*p = C.float(v)
+			bind.buffer = unsafe.Pointer(p)
+		case float64:
+			bind.buffer_type = C.TSDB_DATA_TYPE_DOUBLE
+			p := (*C.double)(alloc(8))
+			*p = C.double(v)
+			bind.buffer = unsafe.Pointer(p)
+		case []byte:
+			bind.buffer_type = C.TSDB_DATA_TYPE_BINARY
+			bindVarData(bind, alloc, string(v))
+		case string:
+			bind.buffer_type = C.TSDB_DATA_TYPE_NCHAR
+			bindVarData(bind, alloc, v)
+		case time.Time:
+			bind.buffer_type = C.TSDB_DATA_TYPE_TIMESTAMP
+			p := (*C.int64_t)(alloc(8))
+			*p = C.int64_t(v.UnixNano() / int64(s.precision))
+			bind.buffer = unsafe.Pointer(p)
+		default:
+			return nil, free, fmt.Errorf("argument %d: unsupported argument type %T", i, arg)
+		}
+	}
+	return binds, free, nil
+}
+
+func bindVarData(bind *C.TAOS_BIND, alloc func(size int) unsafe.Pointer, value string) {
+	buffer := alloc(len(value) + 1)
+	if len(value) > 0 {
+		C.memcpy(buffer, unsafe.Pointer(&[]byte(value)[0]), C.size_t(len(value)))
+	}
+	length := (*C.uintptr_t)(alloc(C.sizeof_uintptr_t))
+	*length = C.uintptr_t(len(value))
+	bind.buffer = buffer
+	bind.buffer_length = C.uintptr_t(len(value))
+	bind.length = length
+}
+
+func (s *nativeStmt) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stmt == nil {
+		return nil
+	}
+	code := C.taos_stmt_close(s.stmt)
+	s.stmt = nil
+	s.pool.put(s.conn)
+	if code != 0 {
+		return nativeCodeError(code)
+	}
+	return nil
+}
+
+// readNativeResult reads every row of res, values have the same Go types as GoConnector.Query.
+// ctx is checked every nativeCheckRows rows.
+func readNativeResult(ctx context.Context, res unsafe.Pointer) (*Data, error) {
+	numFields := int(C.taos_num_fields(res))
+	result := &Data{Head: make([]string, numFields), Columns: make([]*Column, numFields)}
+	if numFields == 0 {
+		return result, nil
+	}
+	fields := (*[1 << 16]C.TAOS_FIELD)(unsafe.Pointer(C.taos_fetch_fields(res)))[:numFields:numFields]
+	for i := range fields {
+		result.Head[i] = C.GoString(&fields[i].name[0])
+		result.Columns[i] = &Column{Name: result.Head[i], Type: int(fields[i]._type), Length: int(fields[i].bytes)}
+	}
+	precision := int(C.taos_result_precision(res))
+	for {
+		if len(result.Data)%nativeCheckRows == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		row := C.taos_fetch_row(res)
+		if row == nil {
+			break
+		}
+		lengths := (*[1 << 16]C.int)(unsafe.Pointer(C.taos_fetch_lengths(res)))[:numFields:numFields]
+		cells := (*[1 << 16]unsafe.Pointer)(unsafe.Pointer(row))[:numFields:numFields]
+		values := make([]interface{}, numFields)
+		for i, p := range cells {
+			if p == nil {
+				continue
+			}
+			values[i] = readNativeValue(int(fields[i]._type), p, int(lengths[i]), precision)
+		}
+		result.Data = append(result.Data, values)
+	}
+	if C.taos_errno(res) != 0 {
+		return nil, nativeResultError(res)
+	}
+	return result, nil
+}
+
+const nativeCheckRows = 1024
+
+func readNativeValue(colType int, p unsafe.Pointer, length int, precision int) interface{} {
+	switch colType {
+	case C.TSDB_DATA_TYPE_BOOL:
+		return *(*int8)(p) != 0
+	case C.TSDB_DATA_TYPE_TINYINT:
+		return *(*int8)(p)
+	case C.TSDB_DATA_TYPE_SMALLINT:
+		return *(*int16)(p)
+	case C.TSDB_DATA_TYPE_INT:
+		return *(*int32)(p)
+	case C.TSDB_DATA_TYPE_BIGINT:
+		return *(*int64)(p)
+	case C.TSDB_DATA_TYPE_UTINYINT:
+		return *(*uint8)(p)
+	case C.TSDB_DATA_TYPE_USMALLINT:
+		return *(*uint16)(p)
+	case C.TSDB_DATA_TYPE_UINT:
+		return *(*uint32)(p)
+	case C.TSDB_DATA_TYPE_UBIGINT:
+		return *(*uint64)(p)
+	case C.TSDB_DATA_TYPE_FLOAT:
+		return *(*float32)(p)
+	case C.TSDB_DATA_TYPE_DOUBLE:
+		return *(*float64)(p)
+	case C.TSDB_DATA_TYPE_BINARY, C.TSDB_DATA_TYPE_NCHAR:
+		return C.GoStringN((*C.char)(p), C.int(length))
+	case C.TSDB_DATA_TYPE_TIMESTAMP:
+		return timestampToTime(*(*int64)(p), precision)
+	case TypeJSON:
+		return C.GoStringN((*C.char)(p), C.int(length))
+	case TypeVarBinary, TypeGeometry:
+		return C.GoBytes(p, C.int(length))
+	}
+	return nil
+}
+
+// schemalessWrite writes lines into db with taos_schemaless_insert.
+// The connection is switched back to the DSN database afterwards, or closed when the DSN has none since no database cannot be selected again.
+func (p *nativePool) schemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	var cProtocol C.int
+	switch protocol {
+	case common.InfluxDBLineProtocol:
+		cProtocol = C.TSDB_SML_LINE_PROTOCOL
+	case common.OpenTSDBTelnetProtocol:
+		cProtocol = C.TSDB_SML_TELNET_PROTOCOL
+	case common.OpenTSDBJSONProtocol:
+		cProtocol = C.TSDB_SML_JSON_PROTOCOL
+	default:
+		return fmt.Errorf("unsupported schemaless protocol %s", protocol)
+	}
+	var cPrecision C.int
+	switch precision {
+	case "":
+		cPrecision = C.TSDB_SML_TIMESTAMP_NOT_CONFIGURED
+	case "h":
+		cPrecision = C.TSDB_SML_TIMESTAMP_HOURS
+	case "m":
+		cPrecision = C.TSDB_SML_TIMESTAMP_MINUTES
+	case "s":
+		cPrecision = C.TSDB_SML_TIMESTAMP_SECONDS
+	case "ms":
+		cPrecision = C.TSDB_SML_TIMESTAMP_MILLI_SECONDS
+	case "us", "u":
+		cPrecision = C.TSDB_SML_TIMESTAMP_MICRO_SECONDS
+	case "ns":
+		cPrecision = C.TSDB_SML_TIMESTAMP_NANO_SECONDS
+	default:
+		return fmt.Errorf("unsupported precision %s", precision)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	if protocol == common.OpenTSDBJSONProtocol {
+		payload, err := joinOpenTSDBJSON(lines)
+		if err != nil {
+			return err
+		}
+		lines = []string{payload}
+	}
+	conn, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		p.put(conn)
+		return err
+	}
+	if err = conn.selectDB(db); err != nil {
+		p.discard(conn)
+		return err
+	}
+	cLines := (*[1 << 28]*C.char)(C.malloc(C.size_t(len(lines)) * C.size_t(unsafe.Sizeof(uintptr(0)))))[:len(lines):len(lines)]
+	for i, line := range lines {
+		cLines[i] = C.CString(line)
+	}
+	res := C.taos_schemaless_insert(conn.taos, &cLines[0], C.int(len(lines)), cProtocol, cPrecision)
+	for _, line := range cLines {
+		C.free(unsafe.Pointer(line))
+	}
+	C.free(unsafe.Pointer(&cLines[0]))
+	if C.taos_errno(res) != 0 {
+		err = nativeResultError(res)
+	}
+	C.taos_free_result(res)
+	if p.dsn.db == db {
+		p.put(conn)
+		return err
+	}
+	if p.dsn.db == "" {
+		p.discard(conn)
+		return err
+	}
+	if selectErr := conn.selectDB(p.dsn.db); selectErr != nil {
+		p.discard(conn)
+		if err == nil {
+			err = selectErr
+		}
+		return err
+	}
+	p.put(conn)
+	return err
+}
+
+func (c *nativeConn) selectDB(db string) error {
+	cdb := C.CString(db)
+	defer C.free(unsafe.Pointer(cdb))
+	if code := C.taos_select_db(c.taos, cdb); code != 0 {
+		return nativeCodeError(code)
+	}
+	return nil
+}
+
+func nativeCodeError(code C.int) error {
+	return &common.TDengineError{Code: int(code) & 0xffff, Desc: C.GoString(C.tstrerror(C.int32_t(code)))}
+}
+
+func nativeResultError(res unsafe.Pointer) error {
+	return &common.TDengineError{Code: int(C.taos_errno(res)) & 0xffff, Desc: C.GoString(C.taos_errstr(res))}
+}
@@ -0,0 +1,196 @@
+package connector
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stmt is a statement with ? placeholders, created by TDengineConnector.Prepare.
+type Stmt interface {
+	Exec(ctx context.Context, args ...interface{}) (int64, error)
+	Query(ctx context.Context, args ...interface{}) (*Data, error)
+	Close() error
+}
+
+var errStmtClosed = errors.New("statement is closed")
+
+// interpolatedStmt is the Stmt of connectors without server side binding, args are interpolated into the sql on every call.
+type interpolatedStmt struct {
+	connector TDengineConnector
+	sql       string
+	closed    bool
+}
+
+func newInterpolatedStmt(connector TDengineConnector, sql string) *interpolatedStmt {
+	return &interpolatedStmt{connector: connector, sql: sql}
+}
+
+func (s *interpolatedStmt) Exec(ctx context.Context, args ...interface{}) (int64, error) {
+	if s.closed {
+		return 0, errStmtClosed
+	}
+	return s.connector.ExecArgs(ctx, s.sql, args...)
+}
+
+func (s *interpolatedStmt) Query(ctx context.Context, args ...interface{}) (*Data, error) {
+	if s.closed {
+		return nil, errStmtClosed
+	}
+	return s.connector.QueryArgs(ctx, s.sql, args...)
+}
+
+func (s *interpolatedStmt) Close() error {
+	s.closed = true
+	return nil
+}
+
+// scanPlaceholders calls fn with the byte offset of every ? outside of ”, "" and “ quotes.
+func scanPlaceholders(sql string, fn func(offset int) error) error {
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// interpolateParams replaces the ? placeholders of sql with args formatted as SQL literals.
+func interpolateParams(sql string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return sql, nil
+	}
+	var b strings.Builder
+	b.Grow(len(sql) + len(args)*16)
+	last := 0
+	argIndex := 0
+	err := scanPlaceholders(sql, func(offset int) error {
+		if argIndex >= len(args) {
+			return fmt.Errorf("sql has more placeholders than the %d arguments given", len(args))
+		}
+		b.WriteString(sql[last:offset])
+		if err := writeLiteral(&b, args[argIndex]); err != nil {
+			return fmt.Errorf("argument %d: %w", argIndex, err)
+		}
+		argIndex++
+		last = offset + 1
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if argIndex != len(args) {
+		return "", fmt.Errorf("sql has %d placeholders but %d arguments given", argIndex, len(args))
+	}
+	b.WriteString(sql[last:])
+	return b.String(), nil
+}
+
+func writeLiteral(b *strings.Builder, arg interface{}) error {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		arg = v
+	}
+	switch v := arg.(type) {
+	case nil:
+		b.WriteString("NULL")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case int:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int8:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int16:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int32:
+		b.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case uint:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint8:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		b.WriteString(strconv.FormatUint(v, 10))
+	case float32:
+		return writeFloat(b, float64(v), 32)
+	case float64:
+		return writeFloat(b, v, 64)
+	case string:
+		writeQuoted(b, v)
+	case []byte:
+		writeQuoted(b, string(v))
+	case time.Time:
+		writeQuoted(b, v.Format(time.RFC3339Nano))
+	default:
+		// 处理自定义的基础类型，例如 type Status int8
+		rv := reflect.ValueOf(arg)
+		switch rv.Kind() {
+		case reflect.Bool:
+			return writeLiteral(b, rv.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return writeLiteral(b, rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return writeLiteral(b, rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			return writeFloat(b, rv.Float(), rv.Type().Bits())
+		case reflect.String:
+			return writeLiteral(b, rv.String())
+		case reflect.Ptr:
+			if rv.IsNil() {
+				return writeLiteral(b, nil)
+			}
+			return writeLiteral(b, rv.Elem().Interface())
+		}
+		return fmt.Errorf("unsupported argument type %T", arg)
+	}
+	return nil
+}
+
+func writeFloat(b *strings.Builder, v float64, bitSize int) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("unsupported float value %v", v)
+	}
+	b.WriteString(strconv.FormatFloat(v, 'g', -1, bitSize))
+	return nil
+}
+
+func writeQuoted(b *strings.Builder, s string) {
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '\'':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+}
@@ -0,0 +1,79 @@
+package connector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStatus int8
+
+func TestInterpolateParams(t *testing.T) {
+	var nilInt *int
+	one := 1
+	tests := []struct {
+		name    string
+		sql     string
+		args    []interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "no args", sql: "select * from t", want: "select * from t"},
+		{name: "numbers", sql: "insert into t values (?, ?, ?, ?)", args: []interface{}{int8(-1), uint64(math.MaxUint64), float32(1.5), true},
+			want: "insert into t values (-1, 18446744073709551615, 1.5, true)"},
+		{name: "quote", sql: "select * from t where v = ?", args: []interface{}{"it's"}, want: `select * from t where v = 'it\'s'`},
+		{name: "backslash", sql: "select * from t where v = ?", args: []interface{}{`a\`}, want: `select * from t where v = 'a\\'`},
+		{name: "injection", sql: "select * from t where v = ?", args: []interface{}{`\' or 1=1 --`}, want: `select * from t where v = '\\\' or 1=1 --'`},
+		{name: "placeholder in literals", sql: "select '?', \"?\", `?`, 'it\\'s ?' from t where v = ?", args: []interface{}{1},
+			want: "select '?', \"?\", `?`, 'it\\'s ?' from t where v = 1"},
+		{name: "bytes", sql: "insert into t values (?)", args: []interface{}{[]byte("x'y")}, want: `insert into t values ('x\'y')`},
+		{name: "time", sql: "insert into t values (?)", args: []interface{}{time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)},
+			want: "insert into t values ('2021-01-02T03:04:05.000000006Z')"},
+		{name: "nil", sql: "insert into t values (?, ?)", args: []interface{}{nil, nilInt}, want: "insert into t values (NULL, NULL)"},
+		{name: "named types and pointers", sql: "insert into t values (?, ?)", args: []interface{}{testStatus(3), &one}, want: "insert into t values (3, 1)"},
+		{name: "unsupported type", sql: "insert into t values (?)", args: []interface{}{struct{}{}}, wantErr: true},
+		{name: "NaN", sql: "insert into t values (?)", args: []interface{}{math.NaN()}, wantErr: true},
+		{name: "too few args", sql: "insert into t values (?, ?)", args: []interface{}{1}, wantErr: true},
+		{name: "too many args", sql: "insert into t values (?)", args: []interface{}{1, 2}, wantErr: true},
+		{name: "placeholder only in a literal", sql: "select '?' from t", args: []interface{}{1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolateParams(tt.sql, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanPlaceholders(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []int
+	}{
+		{sql: "?", want: []int{0}},
+		{sql: "a = ? and b = ?", want: []int{4, 14}},
+		{sql: `'?' "?" ` + "`?`", want: nil},
+		{sql: `'\'?' ?`, want: []int{6}},
+		{sql: "`\\`?", want: []int{3}},
+		{sql: "'unterminated ?", want: nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		err := scanPlaceholders(tt.sql, func(offset int) error {
+			got = append(got, offset)
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, %v, want %v", tt.sql, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package connector
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+type registryTestConfig struct {
+	Address string `default:"localhost:6041"`
+}
+
+func registryTestRegistration(name string) *Registration {
+	return &Registration{
+		Name:      name,
+		NewConfig: func() interface{} { return &registryTestConfig{} },
+		New:       func(conf interface{}) (TDengineConnector, error) { return rowsConnector{}, nil },
+	}
+}
+
+// registerForTest registers a test type removed when the test ends.
+func registerForTest(t *testing.T, name string) {
+	t.Helper()
+	if err := Register(registryTestRegistration(name)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		registryLock.Lock()
+		delete(registry, name)
+		registryLock.Unlock()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(&Registration{Name: "registry-test-invalid"}); err == nil {
+		t.Fatal("registered a type without factory")
+	}
+	registerForTest(t, "registry-test")
+	if err := Register(registryTestRegistration("registry-test")); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("got %v, want a duplicate error", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("MustRegister of a duplicate did not panic")
+			}
+		}()
+		MustRegister(registryTestRegistration(common.TDengineRestfulConnectorType))
+	}()
+	names := Registered()
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("got %v, want sorted names", names)
+	}
+	found := map[string]bool{}
+	for _, name := range names {
+		found[name] = true
+	}
+	if !found["registry-test"] || !found[common.TDengineRestfulConnectorType] || found["registry-test-invalid"] {
+		t.Fatalf("got %v", names)
+	}
+
+	conf, err := NewConfig("registry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.(*registryTestConfig).Address != "localhost:6041" {
+		t.Fatalf("got %+v, want the default address", conf)
+	}
+	c, err := NewTDengineConnector("registry-test", &registryTestConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ConnectorType(c) != "connector.rowsConnector" {
+		t.Fatalf("got %s", ConnectorType(c))
+	}
+}
+
+func TestNewTDengineConnectorErrors(t *testing.T) {
+	registerForTest(t, "registry-test-errors")
+	var nilConf *registryTestConfig
+	tests := []struct {
+		name          string
+		connectorType string
+		conf          interface{}
+		want          string
+	}{
+		{name: "unknown type", connectorType: "nope", want: "unsupported TDengine connector type nope"},
+		{name: "wrong config type", connectorType: "registry-test-errors", conf: &config.TDengineRestful{}, want: "needs a *connector.registryTestConfig config"},
+		{name: "nil config", connectorType: "registry-test-errors", conf: nilConf, want: "got a nil config"},
+		{name: "no DSN support", connectorType: "registry-test-errors", conf: &config.DSN{ConnectorType: "registry-test-errors"}, want: "does not support DSN"},
+		{name: "DSN of another type", connectorType: "registry-test-errors", conf: &config.DSN{ConnectorType: common.TDengineRestfulConnectorType},
+			want: "is for the " + common.TDengineRestfulConnectorType + " connector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTDengineConnector(tt.connectorType, tt.conf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewConfig("nope"); err == nil {
+		t.Fatal("NewConfig of an unknown type succeeded")
+	}
+}
@@ -1,7 +1,6 @@
 package connector
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
@@ -9,11 +8,14 @@ import (
 	"github.com/taosdata/go-utils/json"
 	"github.com/taosdata/go-utils/tdengine/common"
 	"github.com/taosdata/go-utils/tdengine/config"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +29,7 @@ type TDEngineRestfulResp struct {
 	Rows       int             `json:"rows"`
 	Code       int             `json:"code"`
 	Desc       string          `json:"desc"`
+	columns    []*Column
 }
 type RestfulConnector struct {
 	address    string
@@ -34,78 +37,175 @@ type RestfulConnector struct {
 	username   string
 	password   string
 	token      string
-	url        *url.URL
+	tokenLock  sync.RWMutex
+	tokenGen   uint64
+	refreshing *tokenRefresh
 	httpClient *http.Client
-	queryUrl   string
+	balancer   *restfulBalancer
+	retrier    *retrier
+	// compression 为空时不压缩
+	compression    string
+	parseTimestamp timestampParser
+	connStats      *restfulConnStats
+	stop           chan struct{}
+	stopOnce       sync.Once
 }
 
 func NewRestfulConnector(conf *config.TDengineRestful) (*RestfulConnector, error) {
-	var err error
-	connector := &RestfulConnector{address: conf.Address, authType: conf.AuthType, username: conf.Username, password: conf.Password}
-	connector.url, err = url.Parse(conf.Address)
+	connector := &RestfulConnector{address: conf.Address, authType: conf.AuthType, username: conf.Username, password: conf.Password, retrier: newRetrier(&conf.Retry), connStats: newRestfulConnStats(), stop: make(chan struct{})}
+	switch conf.Compression {
+	case "", common.GzipCompression, common.DeflateCompression:
+		connector.compression = conf.Compression
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", conf.Compression)
+	}
+	transport, err := newRestfulTransport(conf)
 	if err != nil {
 		return nil, err
 	}
-	var transport http.RoundTripper = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		ForceAttemptHTTP2:     true,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		MaxConnsPerHost:       conf.MaxConnsPerHost,
-	}
 	connector.httpClient = &http.Client{
 		Transport: transport,
 	}
+	addresses := conf.AddressList()
+	sqlPath, err := restfulSQLPath(conf.TimestampFormat)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Database != "" {
+		sqlPath = path.Join(sqlPath, url.PathEscape(conf.Database))
+	}
+	connector.parseTimestamp, err = newTimestampParser(conf.TimestampFormat, conf.Precision)
+	if err != nil {
+		return nil, err
+	}
+	connector.balancer, err = newRestfulBalancer(addresses, sqlPath, conf.LoadBalance, connector.httpClient)
+	if err != nil {
+		return nil, err
+	}
 	switch conf.AuthType {
 	case common.BasicAuthType:
 		connector.token = base64.StdEncoding.EncodeToString([]byte(conf.Username + ":" + conf.Password))
 	case common.TaosdAuthType:
-		loginUrl := path.Join(connector.url.String(), "/rest/login", conf.Username, conf.Password)
-		resp, err := connector.httpClient.Get(loginUrl)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("get taos token error statusCode: %d,body: %s", resp.StatusCode, string(body))
-		}
-		var respData TDEngineRestfulResp
-		err = json.Unmarshal(body, &respData)
+		connector.token, err = connector.login(context.Background())
 		if err != nil {
 			return nil, err
 		}
-		if respData.Status == "succ" {
-			connector.token = respData.Desc
-		} else {
-			return nil, fmt.Errorf("get taos token error statusCode: %d,body: %s", resp.StatusCode, body)
-		}
+		connector.startTokenRefresh(conf.TokenRefreshInterval)
 	default:
 		return nil, fmt.Errorf("unsupported auth type %s", conf.AuthType)
 	}
-
-	connector.url.Path = path.Join(connector.url.Path, "/rest/sqlutc")
-	connector.queryUrl = connector.url.String()
+	connector.balancer.startHealthCheck(conf.HealthCheckInterval)
 	return connector, nil
 }
 
+func newRestfulTransport(conf *config.TDengineRestful) (http.RoundTripper, error) {
+	if conf.Transport != nil {
+		return conf.Transport, nil
+	}
+	tlsConfig, err := conf.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	// conf 未经过 Init 时字段为零值，使用与 Init 相同的默认值
+	dialTimeout, keepAlive, idleConnTimeout := conf.DialTimeout, conf.KeepAlive, conf.IdleConnTimeout
+	tlsHandshakeTimeout, maxIdleConns := conf.TLSHandshakeTimeout, conf.MaxIdleConns
+	if dialTimeout == 0 {
+		dialTimeout = 30 * time.Second
+	}
+	if keepAlive == 0 {
+		keepAlive = 30 * time.Second
+	}
+	if idleConnTimeout == 0 {
+		idleConnTimeout = 90 * time.Second
+	}
+	if tlsHandshakeTimeout == 0 {
+		tlsHandshakeTimeout = 10 * time.Second
+	}
+	if maxIdleConns == 0 {
+		maxIdleConns = 100
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: keepAlive,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		ResponseHeaderTimeout: conf.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		MaxConnsPerHost:       conf.MaxConnsPerHost,
+	}, nil
+}
+
+// login gets a taosd token, it tries every endpoint starting from the one picked by the balancer.
+func (h *RestfulConnector) login(ctx context.Context) (string, error) {
+	first := h.balancer.pick()
+	token, err := h.loginEndpoint(ctx, first)
+	if err == nil {
+		return token, nil
+	}
+	for _, e := range h.balancer.endpoints {
+		if e == first {
+			continue
+		}
+		if token, err = h.loginEndpoint(ctx, e); err == nil {
+			return token, nil
+		}
+	}
+	return "", err
+}
+
+func (h *RestfulConnector) loginEndpoint(ctx context.Context, e *restfulEndpoint) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, joinUrl(e.base, "/rest/login", h.username, h.password), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := h.httpClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get taos token error statusCode: %d,body: %s", resp.StatusCode, string(body))
+	}
+	var respData TDEngineRestfulResp
+	err = json.Unmarshal(body, &respData)
+	if err != nil {
+		return "", err
+	}
+	if respData.Status != "succ" {
+		return "", fmt.Errorf("get taos token error statusCode: %d,body: %s", resp.StatusCode, body)
+	}
+	return respData.Desc, nil
+}
+
+// Close stops the health check of the endpoints and the token refresh, idle connections are closed.
+func (h *RestfulConnector) Close() error {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+	h.balancer.close()
+	h.httpClient.CloseIdleConnections()
+	return nil
+}
+
 func (h *RestfulConnector) Query(ctx context.Context, sql string) (*Data, error) {
 	data, err := h.query(ctx, sql)
 	if err != nil {
 		return nil, err
 	}
 	return &Data{
-		Head: data.Head,
-		Data: data.Data,
+		Head:    data.Head,
+		Data:    data.Data,
+		Columns: data.columns,
 	}, nil
 }
 
@@ -117,76 +217,357 @@ func (h *RestfulConnector) Exec(ctx context.Context, sql string) (int64, error)
 	return int64(data.Rows), nil
 }
 
-func (h *RestfulConnector) query(ctx context.Context, sql string) (*TDEngineRestfulResp, error) {
-	contentReader := bytes.NewReader([]byte(sql))
-	request, _ := http.NewRequestWithContext(ctx, "POST", h.queryUrl, contentReader)
-	if h.token != "" {
-		request.Header.Set("Authorization", fmt.Sprintf("%s %s", h.authType, h.token))
+func (h *RestfulConnector) QueryRows(ctx context.Context, sql string) (Rows, error) {
+	var rows *restfulRows
+	err := h.retrier.do(ctx, func() error {
+		return h.withAuth(ctx, func() error {
+			resp, err := h.doRequest(ctx, sql)
+			if err != nil {
+				return err
+			}
+			rows, err = newRestfulRows(resp.Body, sql, h.parseTimestamp)
+			if err != nil {
+				resp.Body.Close()
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	resp, err := h.httpClient.Do(request)
+	return rows, nil
+}
+
+// Prepare returns a statement whose args are interpolated on the client side, see ExecArgs.
+func (h *RestfulConnector) Prepare(ctx context.Context, sql string) (Stmt, error) {
+	return newInterpolatedStmt(h, sql), nil
+}
+
+// ExecArgs interpolates args into sql as escaped literals since the endpoint has no parameter binding.
+func (h *RestfulConnector) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	sql, err := interpolateParams(sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return h.Exec(ctx, sql)
+}
+
+// QueryArgs interpolates args into sql as escaped literals since the endpoint has no parameter binding.
+func (h *RestfulConnector) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error) {
+	sql, err := interpolateParams(sql, args)
 	if err != nil {
 		return nil, err
 	}
+	return h.Query(ctx, sql)
+}
+
+// SchemalessWrite writes lines through the InfluxDB and OpenTSDB compatible endpoints of taosAdapter.
+func (h *RestfulConnector) SchemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	return h.retrier.do(ctx, func() error {
+		return h.withAuth(ctx, func() error {
+			return h.schemalessWrite(ctx, db, protocol, lines, precision)
+		})
+	})
+}
+
+func (h *RestfulConnector) schemalessWrite(ctx context.Context, db string, protocol string, lines []string, precision string) error {
+	e := h.balancer.pick()
+	u := *e.base
+	switch protocol {
+	case common.InfluxDBLineProtocol:
+		u.Path = path.Join(u.Path, "/influxdb/v1/write")
+		query := url.Values{}
+		query.Set("db", db)
+		if precision != "" {
+			// influxdb 接口中微秒为 u
+			if precision == "us" {
+				precision = "u"
+			}
+			query.Set("precision", precision)
+		}
+		u.RawQuery = query.Encode()
+	case common.OpenTSDBTelnetProtocol:
+		u.Path = path.Join(u.Path, "/opentsdb/v1/put/telnet", db)
+	case common.OpenTSDBJSONProtocol:
+		u.Path = path.Join(u.Path, "/opentsdb/v1/put/json", db)
+	default:
+		return fmt.Errorf("unsupported schemaless protocol %s", protocol)
+	}
+	body := strings.Join(lines, "\n")
+	if protocol == common.OpenTSDBJSONProtocol {
+		var err error
+		if body, err = joinOpenTSDBJSON(lines); err != nil {
+			return err
+		}
+	}
+	request, err := h.newRequest(ctx, u.String(), []byte(body))
+	if err != nil {
+		return err
+	}
+	resp, err := h.do(e, request)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var respData struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respBody, &respData) == nil && respData.Message != "" {
+		return &common.TDengineError{Code: respData.Code, Desc: respData.Message}
+	}
+	return &common.HTTPStatusError{StatusCode: resp.StatusCode, Body: fmt.Sprintf("schemaless write error statusCode: %d,body: %s", resp.StatusCode, respBody)}
+}
+
+func (h *RestfulConnector) query(ctx context.Context, sql string) (*TDEngineRestfulResp, error) {
+	var data *TDEngineRestfulResp
+	err := h.retrier.do(ctx, func() error {
+		return h.withAuth(ctx, func() error {
+			var err error
+			data, err = h.doQuery(ctx, sql)
+			return err
+		})
+	})
+	return data, err
+}
+
+func (h *RestfulConnector) doQuery(ctx context.Context, sql string) (*TDEngineRestfulResp, error) {
+	raw, err := h.doQueryRaw(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	data, err := raw.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return &TDEngineRestfulResp{Status: "succ", Head: data.Head, Data: data.Data, Rows: raw.Rows, columns: data.Columns}, nil
+}
+
+func (h *RestfulConnector) doRequest(ctx context.Context, sql string) (*http.Response, error) {
+	e := h.balancer.pick()
+	request, err := h.newRequest(ctx, e.queryUrl, []byte(sql))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.do(e, request)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != 200 && resp.StatusCode != 400 {
+		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New(string(body))
+		return nil, &common.HTTPStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
-	var data *TDEngineRestfulResp
-	err = json.NewDecoder(resp.Body).Decode(&data)
+	return resp, nil
+}
+
+// do sends request to e and reports the outcome to the balancer, the in-flight slot is held until the body is closed.
+func (h *RestfulConnector) do(e *restfulEndpoint, request *http.Request) (*http.Response, error) {
+	h.balancer.acquire(e)
+	resp, err := h.httpClient.Do(h.connStats.traceRequest(request))
 	if err != nil {
+		h.balancer.release(e)
+		h.balancer.report(e, err, 0)
 		return nil, err
 	}
-	if data.Status != "succ" {
-		if data.Desc != "" {
-			return data, &common.TDengineError{Code: data.Code, Desc: data.Desc}
-		}
-		return data, fmt.Errorf("query: %s error,response body: %#v", sql, data)
+	h.balancer.report(e, nil, resp.StatusCode)
+	resp.Body = &endpointBody{ReadCloser: resp.Body, release: func() { h.balancer.release(e) }}
+	if err = decompressBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
 	}
-	//构建 head data
-	data.Head = make([]string, 0, len(data.ColumnMeta))
-	types := make([]int, len(data.ColumnMeta))
-	for index, columnMessage := range data.ColumnMeta {
-		colName := columnMessage[0].(string)
-		colType := columnMessage[1].(float64)
-		types[index] = int(colType)
-		data.Head = append(data.Head, colName)
+	return resp, nil
+}
+
+// restfulRows decodes the data array of a restful response one row at a time.
+type restfulRows struct {
+	body           io.ReadCloser
+	decoder        *json.TokenDecoder
+	columns        []string
+	columnTypes    []*Column
+	types          []int
+	parseTimestamp timestampParser
+	values         []interface{}
+	err            error
+	done           bool
+	// fromBuffer 为 true 时从 buffered 读取行，data 先于 status 或 column_meta 出现时使用
+	fromBuffer bool
+	buffered   [][]json.RawMessage
+}
+
+func newRestfulRows(body io.ReadCloser, sql string, parseTimestamp timestampParser) (*restfulRows, error) {
+	decoder := json.NewTokenDecoder(body)
+	decoder.UseNumber()
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
 	}
-	for rowIndex, row := range data.Data {
-		for columnIndex, value := range row {
-			if value == nil {
-				continue
-			}
-			switch value.(type) {
-			case string:
-				if types[columnIndex] == 9 {
-					t, _ := time.Parse(Layout, value.(string))
-					data.Data[rowIndex][columnIndex] = t
+	var (
+		status     string
+		code       int
+		desc       string
+		columnMeta [][]interface{}
+		// data 在 status 或 column_meta 之前出现时先缓存，读完整个响应后再判断
+		buffered [][]json.RawMessage
+	)
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "status":
+			err = decoder.Decode(&status)
+		case "code":
+			err = decoder.Decode(&code)
+		case "desc":
+			err = decoder.Decode(&desc)
+		case "column_meta":
+			err = decoder.Decode(&columnMeta)
+			json.ConvertNumbers(&columnMeta)
+		case "data":
+			if status == "succ" && columnMeta != nil {
+				if err = expectDelim(decoder, '['); err != nil {
+					return nil, err
 				}
-			case float64:
-				switch types[columnIndex] {
-				case 1:
-					data.Data[rowIndex][columnIndex] = int(value.(float64)) == 1
-				case 2:
-					data.Data[rowIndex][columnIndex] = int8(value.(float64))
-				case 3:
-					data.Data[rowIndex][columnIndex] = int16(value.(float64))
-				case 4:
-					data.Data[rowIndex][columnIndex] = int32(value.(float64))
-				case 5:
-					data.Data[rowIndex][columnIndex] = int64(value.(float64))
-				case 6:
-					data.Data[rowIndex][columnIndex] = float32(value.(float64))
-				case 7:
-					data.Data[rowIndex][columnIndex] = value.(float64)
+				rows, err := newRestfulRowsOf(body, decoder, columnMeta, parseTimestamp)
+				if err != nil {
+					return nil, err
 				}
+				return rows, nil
+			}
+			if status != "" && status != "succ" {
+				var discard json.RawMessage
+				err = decoder.Decode(&discard)
+				break
 			}
+			err = decoder.Decode(&buffered)
+		default:
+			var discard json.RawMessage
+			err = decoder.Decode(&discard)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if status != "succ" {
+		if desc != "" {
+			return nil, &common.TDengineError{Code: code, Desc: desc}
+		}
+		return nil, fmt.Errorf("query: %s error,response status: %s", sql, status)
+	}
+	if buffered != nil {
+		if columnMeta == nil {
+			return nil, errors.New("column_meta missing in response body")
+		}
+		rows, err := newRestfulRowsOf(body, decoder, columnMeta, parseTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		rows.buffered, rows.fromBuffer = buffered, true
+		return rows, nil
+	}
+	return &restfulRows{body: body, decoder: decoder, columns: []string{}, columnTypes: []*Column{}, done: true}, nil
+}
+
+func newRestfulRowsOf(body io.ReadCloser, decoder *json.TokenDecoder, columnMeta [][]interface{}, parseTimestamp timestampParser) (*restfulRows, error) {
+	columns, err := parseColumnMeta(columnMeta)
+	if err != nil {
+		return nil, err
+	}
+	rows := &restfulRows{
+		body:           body,
+		decoder:        decoder,
+		columns:        make([]string, len(columns)),
+		columnTypes:    columns,
+		types:          make([]int, len(columns)),
+		parseTimestamp: parseTimestamp,
+	}
+	for index, column := range columns {
+		rows.columns[index] = column.Name
+		rows.types[index] = column.Type
+	}
+	return rows, nil
+}
+
+func expectDelim(decoder *json.TokenDecoder, delim json.Delim) error {
+	t, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected token %v in response body, expected %v", t, delim)
+	}
+	return nil
+}
+
+func (r *restfulRows) Columns() []string {
+	return r.columns
+}
+
+func (r *restfulRows) ColumnTypes() []*Column {
+	return r.columnTypes
+}
+
+func (r *restfulRows) Next() bool {
+	if r.done || r.err != nil {
+		return false
+	}
+	var raw []json.RawMessage
+	if r.fromBuffer {
+		if len(r.buffered) == 0 {
+			r.done = true
+			r.values = nil
+			return false
+		}
+		raw, r.buffered = r.buffered[0], r.buffered[1:]
+	} else {
+		if !r.decoder.More() {
+			r.done = true
+			r.values = nil
+			return false
+		}
+		if err := r.decoder.Decode(&raw); err != nil {
+			r.err = err
+			r.values = nil
+			return false
 		}
 	}
-	return data, nil
+	row := make([]interface{}, len(raw))
+	for columnIndex, cell := range raw {
+		value, err := decodeRawValue(r.types[columnIndex], cell, r.parseTimestamp)
+		if err != nil {
+			r.err = err
+			r.values = nil
+			return false
+		}
+		row[columnIndex] = value
+	}
+	r.values = row
+	return true
+}
+
+func (r *restfulRows) Scan(dest ...interface{}) error {
+	return scanRow(r.values, dest)
+}
+
+func (r *restfulRows) Err() error {
+	return r.err
+}
+
+func (r *restfulRows) Close() error {
+	r.done = true
+	r.values = nil
+	return r.body.Close()
 }
 
 type RawTDEngineRestfulResp struct {
@@ -197,3 +578,7 @@ type RawTDEngineRestfulResp struct {
 	Code       int                 `json:"code"`
 	Desc       string              `json:"desc"`
 }
+
+func (h *RestfulConnector) connectorType() string {
+	return common.TDengineRestfulConnectorType
+}
@@ -0,0 +1,123 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	taosErrors "github.com/taosdata/driver-go/errors"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"net/http"
+	"time"
+)
+
+// currentToken returns the token and its generation, the generation changes every time the token is refreshed.
+func (h *RestfulConnector) currentToken() (string, uint64) {
+	h.tokenLock.RLock()
+	defer h.tokenLock.RUnlock()
+	return h.token, h.tokenGen
+}
+
+// RefreshToken logs in again and replaces the taosd token, it does nothing for basic auth.
+func (h *RestfulConnector) RefreshToken(ctx context.Context) error {
+	if h.authType != common.TaosdAuthType {
+		return nil
+	}
+	_, gen := h.currentToken()
+	return h.refreshToken(ctx, gen)
+}
+
+// tokenRefresh is a login in flight, err is set before done is closed.
+type tokenRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshToken logs in only if the token is still of generation gen,
+// so concurrent requests failing with the same stale token log in once and wait for that login.
+// The lock is not held during the login, requests with the current token are not blocked by a slow one.
+func (h *RestfulConnector) refreshToken(ctx context.Context, gen uint64) error {
+	h.tokenLock.Lock()
+	if h.tokenGen != gen {
+		h.tokenLock.Unlock()
+		return nil
+	}
+	if refresh := h.refreshing; refresh != nil {
+		h.tokenLock.Unlock()
+		select {
+		case <-refresh.done:
+			return refresh.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	refresh := &tokenRefresh{done: make(chan struct{})}
+	h.refreshing = refresh
+	h.tokenLock.Unlock()
+
+	token, err := h.login(ctx)
+	h.tokenLock.Lock()
+	if err == nil {
+		h.token = token
+		h.tokenGen++
+	}
+	h.refreshing = nil
+	h.tokenLock.Unlock()
+	refresh.err = err
+	close(refresh.done)
+	return err
+}
+
+// withAuth calls fn and, when fn fails because the taosd token is rejected, refreshes the token and calls fn once more.
+func (h *RestfulConnector) withAuth(ctx context.Context, fn func() error) error {
+	if h.authType != common.TaosdAuthType {
+		return fn()
+	}
+	_, gen := h.currentToken()
+	err := fn()
+	if !isAuthError(err) {
+		return err
+	}
+	if refreshErr := h.refreshToken(ctx, gen); refreshErr != nil {
+		return err
+	}
+	return fn()
+}
+
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusError *common.HTTPStatusError
+	if errors.As(err, &statusError) {
+		return statusError.StatusCode == http.StatusUnauthorized
+	}
+	var tdengineError *common.TDengineError
+	if errors.As(err, &tdengineError) {
+		switch int32(tdengineError.Code & 0xffff) {
+		case taosErrors.HTTP_NO_AUTH_INFO, taosErrors.HTTP_INVALID_AUTH_FORMAT, taosErrors.HTTP_INVALID_TAOSD_AUTH:
+			return true
+		}
+	}
+	return false
+}
+
+// startTokenRefresh refreshes the token every interval until Close, a zero interval disables it.
+func (h *RestfulConnector) startTokenRefresh(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				// 失败时保留旧 token，请求鉴权失败时会再次登录
+				_ = h.RefreshToken(ctx)
+				cancel()
+			}
+		}
+	}()
+}
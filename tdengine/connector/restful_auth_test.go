@@ -0,0 +1,68 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+func TestRestfulRefreshTokenConcurrent(t *testing.T) {
+	var logins int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/rest/login/") {
+			http.NotFound(w, r)
+			return
+		}
+		n := atomic.AddInt32(&logins, 1)
+		if n > 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		fmt.Fprintf(w, `{"status":"succ","code":0,"desc":"token-%d"}`, n)
+	}))
+	defer server.Close()
+	conf := &config.TDengineRestful{Address: server.URL, AuthType: common.TaosdAuthType}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewRestfulConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connector.Close()
+	_, gen := connector.currentToken()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- connector.refreshToken(context.Background(), gen)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	connector.currentToken()
+	if blocked := time.Since(start); blocked > 50*time.Millisecond {
+		t.Errorf("reading the token was blocked %s by the login in flight", blocked)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token, _ := connector.currentToken(); atomic.LoadInt32(&logins) != 2 || token != "token-2" {
+		t.Fatalf("logged in %d times with token %s, want one login for the concurrent refreshes", logins, token)
+	}
+}
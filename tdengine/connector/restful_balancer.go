@@ -0,0 +1,229 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// restfulEjectFailures 连续失败多少次后摘除地址
+	restfulEjectFailures = 3
+	// restfulEjectBackoff 没有健康检查时，摘除的地址经过此时间后放行一个试探请求
+	restfulEjectBackoff = 30 * time.Second
+)
+
+// restfulEndpoint is one taosAdapter address, inFlight counts requests whose response body is not closed yet.
+type restfulEndpoint struct {
+	base     *url.URL
+	queryUrl string
+	inFlight int64
+	healthy  int32
+	// failures 为连续失败次数，ejectedAt 为摘除或上次试探的时间
+	failures  int32
+	ejectedAt int64
+}
+
+func newRestfulEndpoint(address string, sqlPath string) (*restfulEndpoint, error) {
+	base, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid TDengine address %s", address)
+	}
+	return &restfulEndpoint{base: base, queryUrl: joinUrl(base, sqlPath), healthy: 1}, nil
+}
+
+func joinUrl(base *url.URL, elem ...string) string {
+	u := *base
+	u.Path = path.Join(append([]string{u.Path}, elem...)...)
+	return u.String()
+}
+
+func (e *restfulEndpoint) isHealthy() bool {
+	return atomic.LoadInt32(&e.healthy) == 1
+}
+
+// restfulBalancer spreads requests across the endpoints, endpoints failing restfulEjectFailures times in a row with
+// transport errors or 5xx responses are ejected until the periodic health check (GET /-/ping) succeeds again.
+// Without health check, an ejected endpoint gets one trial request every ejectBackoff and is readmitted when it succeeds.
+type restfulBalancer struct {
+	endpoints    []*restfulEndpoint
+	policy       string
+	next         uint64
+	httpClient   *http.Client
+	healthCheck  bool
+	ejectBackoff time.Duration
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+func newRestfulBalancer(addresses []string, sqlPath string, policy string, httpClient *http.Client) (*restfulBalancer, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no TDengine address")
+	}
+	switch policy {
+	case "", common.RoundRobinBalance:
+		policy = common.RoundRobinBalance
+	case common.LeastInFlightBalance:
+	default:
+		return nil, fmt.Errorf("unsupported load balance policy %s", policy)
+	}
+	b := &restfulBalancer{policy: policy, httpClient: httpClient, ejectBackoff: restfulEjectBackoff, stop: make(chan struct{})}
+	for _, address := range addresses {
+		e, err := newRestfulEndpoint(address, sqlPath)
+		if err != nil {
+			return nil, err
+		}
+		b.endpoints = append(b.endpoints, e)
+	}
+	return b, nil
+}
+
+// pick returns a healthy endpoint, or an ejected one due for a trial, when every endpoint is ejected all of them are candidates.
+func (b *restfulBalancer) pick() *restfulEndpoint {
+	if len(b.endpoints) == 1 {
+		return b.endpoints[0]
+	}
+	candidates := make([]*restfulEndpoint, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		if e.isHealthy() {
+			candidates = append(candidates, e)
+		} else if b.trial(e) {
+			return e
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+	n := atomic.AddUint64(&b.next, 1)
+	if b.policy == common.LeastInFlightBalance {
+		// 从轮询位置开始找，避免负载相同时总是选中第一个
+		best := candidates[n%uint64(len(candidates))]
+		for i := range candidates {
+			e := candidates[(n+uint64(i))%uint64(len(candidates))]
+			if atomic.LoadInt64(&e.inFlight) < atomic.LoadInt64(&best.inFlight) {
+				best = e
+			}
+		}
+		return best
+	}
+	return candidates[n%uint64(len(candidates))]
+}
+
+func (b *restfulBalancer) acquire(e *restfulEndpoint) {
+	atomic.AddInt64(&e.inFlight, 1)
+}
+
+func (b *restfulBalancer) release(e *restfulEndpoint) {
+	atomic.AddInt64(&e.inFlight, -1)
+}
+
+// trial reports whether the ejected endpoint e may get a trial request, at most one every ejectBackoff.
+func (b *restfulBalancer) trial(e *restfulEndpoint) bool {
+	if b.healthCheck {
+		return false
+	}
+	ejectedAt := atomic.LoadInt64(&e.ejectedAt)
+	now := time.Now().UnixNano()
+	if now-ejectedAt < int64(b.ejectBackoff) {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&e.ejectedAt, ejectedAt, now)
+}
+
+// report ejects e once err or statusCode showed restfulEjectFailures times in a row that the endpoint itself is failing,
+// a success readmits it.
+func (b *restfulBalancer) report(e *restfulEndpoint, err error, statusCode int) {
+	if len(b.endpoints) == 1 {
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	if err != nil || statusCode >= 500 {
+		if atomic.AddInt32(&e.failures, 1) >= restfulEjectFailures {
+			b.eject(e)
+		}
+		return
+	}
+	atomic.StoreInt32(&e.failures, 0)
+	atomic.StoreInt32(&e.healthy, 1)
+}
+
+func (b *restfulBalancer) eject(e *restfulEndpoint) {
+	if atomic.CompareAndSwapInt32(&e.healthy, 1, 0) {
+		atomic.StoreInt64(&e.ejectedAt, time.Now().UnixNano())
+	}
+}
+
+// startHealthCheck checks every endpoint each interval until close, it does nothing for a single endpoint.
+func (b *restfulBalancer) startHealthCheck(interval time.Duration) {
+	if len(b.endpoints) == 1 || interval <= 0 {
+		return
+	}
+	b.healthCheck = true
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				for _, e := range b.endpoints {
+					if b.check(e, interval) {
+						atomic.StoreInt32(&e.failures, 0)
+						atomic.StoreInt32(&e.healthy, 1)
+					} else {
+						b.eject(e)
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (b *restfulBalancer) check(e *restfulEndpoint, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, joinUrl(e.base, "/-/ping"), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := b.httpClient.Do(request)
+	if err != nil {
+		return false
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode/100 == 2
+}
+
+func (b *restfulBalancer) close() {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+}
+
+// endpointBody releases the in-flight slot of the endpoint once the response body is closed.
+type endpointBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *endpointBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
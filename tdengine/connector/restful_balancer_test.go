@@ -0,0 +1,69 @@
+package connector
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRestfulBalancerEject(t *testing.T) {
+	b, err := newRestfulBalancer([]string{"http://a:6041", "http://b:6041"}, "/rest/sql", "", http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := b.endpoints[0]
+	for i := 1; i < restfulEjectFailures; i++ {
+		b.report(a, errors.New("connection refused"), 0)
+		if !a.isHealthy() {
+			t.Fatalf("ejected after %d failures, want %d", i, restfulEjectFailures)
+		}
+	}
+	b.report(a, nil, http.StatusOK)
+	for i := 1; i < restfulEjectFailures; i++ {
+		b.report(a, nil, http.StatusBadGateway)
+	}
+	if !a.isHealthy() {
+		t.Fatal("a success did not reset the failure count")
+	}
+	b.report(a, nil, http.StatusBadGateway)
+	if a.isHealthy() {
+		t.Fatalf("still healthy after %d failures", restfulEjectFailures)
+	}
+	for i := 0; i < 4; i++ {
+		if e := b.pick(); e != b.endpoints[1] {
+			t.Fatalf("picked %s, want the healthy endpoint", e.base)
+		}
+	}
+}
+
+func TestRestfulBalancerReadmit(t *testing.T) {
+	b, err := newRestfulBalancer([]string{"http://a:6041", "http://b:6041"}, "/rest/sql", "", http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.ejectBackoff = 20 * time.Millisecond
+	a := b.endpoints[0]
+	for i := 0; i < restfulEjectFailures; i++ {
+		b.report(a, errors.New("connection refused"), 0)
+	}
+	if e := b.pick(); e == a {
+		t.Fatal("picked the ejected endpoint before the backoff")
+	}
+	time.Sleep(b.ejectBackoff)
+	if e := b.pick(); e != a {
+		t.Fatalf("picked %s, want a trial on the ejected endpoint", e.base)
+	}
+	if e := b.pick(); e == a {
+		t.Fatal("picked the ejected endpoint twice in one backoff")
+	}
+	b.report(a, errors.New("connection refused"), 0)
+	time.Sleep(b.ejectBackoff)
+	if e := b.pick(); e != a {
+		t.Fatalf("picked %s, want another trial on the ejected endpoint", e.base)
+	}
+	b.report(a, nil, http.StatusOK)
+	if !a.isHealthy() {
+		t.Fatal("a successful trial did not readmit the endpoint")
+	}
+}
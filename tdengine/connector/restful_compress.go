@@ -0,0 +1,101 @@
+package connector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 小于该长度的请求体压缩收益不大，不压缩
+const compressMinSize = 1024
+
+// newRequest creates a POST request with the auth header, body is compressed when compression is enabled.
+func (h *RestfulConnector) newRequest(ctx context.Context, url string, body []byte) (*http.Request, error) {
+	var contentEncoding string
+	if h.compression != "" && len(body) >= compressMinSize {
+		compressed, err := compress(h.compression, body)
+		if err != nil {
+			return nil, err
+		}
+		body = compressed
+		contentEncoding = h.compression
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentEncoding != "" {
+		request.Header.Set("Content-Encoding", contentEncoding)
+	}
+	if h.compression != "" {
+		// 显式设置后 http.Transport 不再自动解压，由 decompressBody 处理
+		request.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	if token, _ := h.currentToken(); token != "" {
+		request.Header.Set("Authorization", fmt.Sprintf("%s %s", h.authType, token))
+	}
+	return request, nil
+}
+
+// compress encodes body with gzip or deflate, deflate follows the HTTP content coding (RFC 9110 8.4.1.2),
+// that is a zlib stream (RFC 1950) wrapping the DEFLATE data, as net/http and taosAdapter expect, not raw DEFLATE.
+func compress(encoding string, body []byte) ([]byte, error) {
+	var b bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case common.GzipCompression:
+		w = gzip.NewWriter(&b)
+	case common.DeflateCompression:
+		w = zlib.NewWriter(&b)
+	default:
+		return nil, fmt.Errorf("unsupported compression %s", encoding)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// decompressBody replaces the body of resp with a reader of the decoded stream according to Content-Encoding,
+// deflate is read as a zlib stream like in compress.
+func decompressBody(resp *http.Response) error {
+	var reader io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return nil
+	case common.GzipCompression:
+		reader, err = gzip.NewReader(resp.Body)
+	case common.DeflateCompression:
+		reader, err = zlib.NewReader(resp.Body)
+	default:
+		return fmt.Errorf("unsupported Content-Encoding %s", resp.Header.Get("Content-Encoding"))
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body = &decompressedBody{ReadCloser: reader, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
+
+type decompressedBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,56 @@
+package connector
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+)
+
+// insertSQL builds an insert of rows rows, the usual body sent through the restful connector.
+func insertSQL(rows int) []byte {
+	var b strings.Builder
+	b.WriteString("insert into db.t0 using db.st tags('beijing', 1) values")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, " (%d, %d.%d, %d, 'device_%d')", 1640995200000+i*1000, 20+i%10, i%100, i%2, i%16)
+	}
+	return []byte(b.String())
+}
+
+func TestCompressDeflateIsZlib(t *testing.T) {
+	body := insertSQL(100)
+	compressed, err := compress(common.DeflateCompression, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ioutil.ReadAll(reader)
+	if err != nil || !bytes.Equal(decoded, body) {
+		t.Fatalf("zlib decoded %d bytes, %v, want the %d bytes of the body", len(decoded), err, len(body))
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	body := insertSQL(1000)
+	for _, encoding := range []string{common.GzipCompression, common.DeflateCompression} {
+		b.Run(encoding, func(b *testing.B) {
+			var compressed []byte
+			var err error
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				if compressed, err = compress(encoding, body); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(body)), "uncompressed_bytes")
+			b.ReportMetric(float64(len(compressed)), "compressed_bytes")
+		})
+	}
+}
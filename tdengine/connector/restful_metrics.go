@@ -0,0 +1,83 @@
+package connector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// restfulConnStats counts the connections used by the requests, new ones and reused idle ones.
+type restfulConnStats struct {
+	created     uint64
+	reused      uint64
+	clientTrace *httptrace.ClientTrace
+}
+
+func newRestfulConnStats() *restfulConnStats {
+	s := &restfulConnStats{}
+	s.clientTrace = &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddUint64(&s.reused, 1)
+			} else {
+				atomic.AddUint64(&s.created, 1)
+			}
+		},
+	}
+	return s
+}
+
+func (s *restfulConnStats) traceRequest(request *http.Request) *http.Request {
+	return request.WithContext(httptrace.WithClientTrace(request.Context(), s.clientTrace))
+}
+
+var (
+	restfulEndpointInFlightDesc = prometheus.NewDesc(
+		"tdengine_restful_endpoint_in_flight_requests",
+		"Requests to a taosAdapter endpoint whose response is not read yet.",
+		[]string{"name", "endpoint"}, nil,
+	)
+	restfulEndpointHealthyDesc = prometheus.NewDesc(
+		"tdengine_restful_endpoint_healthy",
+		"Whether a taosAdapter endpoint receives requests, 0 once ejected by the load balancer.",
+		[]string{"name", "endpoint"}, nil,
+	)
+	restfulConnectionsDesc = prometheus.NewDesc(
+		"tdengine_restful_connections_total",
+		"Connections used by the requests, reused is true for idle connections taken from the transport pool.",
+		[]string{"name", "reused"}, nil,
+	)
+)
+
+type restfulCollector struct {
+	name      string
+	connector *RestfulConnector
+}
+
+// Collector returns a Prometheus collector of the endpoint and HTTP transport stats of the connector,
+// name tells connectors apart when several are registered.
+func (h *RestfulConnector) Collector(name string) prometheus.Collector {
+	return &restfulCollector{name: name, connector: h}
+}
+
+func (c *restfulCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- restfulEndpointInFlightDesc
+	ch <- restfulEndpointHealthyDesc
+	ch <- restfulConnectionsDesc
+}
+
+func (c *restfulCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, e := range c.connector.balancer.endpoints {
+		endpoint := e.base.String()
+		healthy := 0.0
+		if e.isHealthy() {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(restfulEndpointInFlightDesc, prometheus.GaugeValue, float64(atomic.LoadInt64(&e.inFlight)), c.name, endpoint)
+		ch <- prometheus.MustNewConstMetric(restfulEndpointHealthyDesc, prometheus.GaugeValue, healthy, c.name, endpoint)
+	}
+	stats := c.connector.connStats
+	ch <- prometheus.MustNewConstMetric(restfulConnectionsDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&stats.created)), c.name, "false")
+	ch <- prometheus.MustNewConstMetric(restfulConnectionsDesc, prometheus.CounterValue, float64(atomic.LoadUint64(&stats.reused)), c.name, "true")
+}
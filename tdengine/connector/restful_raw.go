@@ -0,0 +1,207 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"strconv"
+	"strings"
+)
+
+// RawData is a query result whose cells are kept as the JSON text returned by taosAdapter,
+// it can be forwarded as is or decoded cell by cell with Value.
+type RawData struct {
+	Columns []*Column           `json:"columns"`
+	Data    [][]json.RawMessage `json:"data"`
+	Rows    int                 `json:"rows"`
+
+	parseTimestamp timestampParser
+}
+
+// Value decodes the cell at row and col to the same Go type as Query.
+func (d *RawData) Value(row, col int) (interface{}, error) {
+	return decodeRawValue(d.Columns[col].Type, d.Data[row][col], d.timestampParser())
+}
+
+func (d *RawData) timestampParser() timestampParser {
+	if d.parseTimestamp == nil {
+		return defaultTimestampParser
+	}
+	return d.parseTimestamp
+}
+
+// ToData decodes every cell.
+func (d *RawData) ToData() (*Data, error) {
+	result := &Data{Head: make([]string, len(d.Columns)), Data: make([][]interface{}, len(d.Data)), Columns: d.Columns}
+	for i, column := range d.Columns {
+		result.Head[i] = column.Name
+	}
+	for rowIndex, row := range d.Data {
+		values := make([]interface{}, len(row))
+		for columnIndex, cell := range row {
+			v, err := decodeRawValue(d.Columns[columnIndex].Type, cell, d.timestampParser())
+			if err != nil {
+				return nil, err
+			}
+			values[columnIndex] = v
+		}
+		result.Data[rowIndex] = values
+	}
+	return result, nil
+}
+
+// QueryRaw runs sql without decoding the cells.
+func (h *RestfulConnector) QueryRaw(ctx context.Context, sql string) (*RawData, error) {
+	var data *RawData
+	err := h.retrier.do(ctx, func() error {
+		return h.withAuth(ctx, func() error {
+			var err error
+			data, err = h.doQueryRaw(ctx, sql)
+			return err
+		})
+	})
+	return data, err
+}
+
+func (h *RestfulConnector) doQueryRaw(ctx context.Context, sql string) (*RawData, error) {
+	resp, err := h.doRequest(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var data RawTDEngineRestfulResp
+	decoder := json.NewDecoder(resp.Body)
+	// column_meta 等数值不经过 float64
+	decoder.UseNumber()
+	err = decoder.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	json.ConvertNumbers(&data.ColumnMeta)
+	if data.Status != "succ" {
+		if data.Desc != "" {
+			return nil, &common.TDengineError{Code: data.Code, Desc: data.Desc}
+		}
+		return nil, fmt.Errorf("query: %s error,response body: %#v", sql, data)
+	}
+	columns, err := parseColumnMeta(data.ColumnMeta)
+	if err != nil {
+		return nil, err
+	}
+	return &RawData{Columns: columns, Data: data.Data, Rows: data.Rows, parseTimestamp: h.parseTimestamp}, nil
+}
+
+// parseColumnMeta converts column_meta, each item is [name, type, length].
+func parseColumnMeta(columnMeta [][]interface{}) ([]*Column, error) {
+	columns := make([]*Column, len(columnMeta))
+	for i, meta := range columnMeta {
+		if len(meta) < 3 {
+			return nil, fmt.Errorf("invalid column_meta %v", meta)
+		}
+		name, ok := meta[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid column_meta %v", meta)
+		}
+		colType, err := metaInt(meta[1])
+		if err != nil {
+			return nil, err
+		}
+		length, err := metaInt(meta[2])
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = &Column{Name: name, Type: colType, Length: length}
+	}
+	return columns, nil
+}
+
+func metaInt(v interface{}) (int, error) {
+	switch v := v.(type) {
+	case float64:
+		return int(v), nil
+	case json.Number:
+		i, err := v.Int64()
+		return int(i), err
+	}
+	return 0, fmt.Errorf("invalid column_meta value %v", v)
+}
+
+var jsonNull = []byte("null")
+
+// decodeRawValue decodes a JSON cell according to the column type without going through interface{} and float64.
+func decodeRawValue(colType int, raw []byte, parseTimestamp timestampParser) (interface{}, error) {
+	if len(raw) == 0 || bytes.Equal(raw, jsonNull) {
+		return nil, nil
+	}
+	switch colType {
+	case TypeBool:
+		switch string(raw) {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid BOOL value %s", raw)
+	case TypeTinyInt:
+		v, err := strconv.ParseInt(string(raw), 10, 8)
+		return int8(v), err
+	case TypeSmallInt:
+		v, err := strconv.ParseInt(string(raw), 10, 16)
+		return int16(v), err
+	case TypeInt:
+		v, err := strconv.ParseInt(string(raw), 10, 32)
+		return int32(v), err
+	case TypeBigInt:
+		return strconv.ParseInt(string(raw), 10, 64)
+	case TypeUnsignedTinyInt:
+		v, err := strconv.ParseUint(string(raw), 10, 8)
+		return uint8(v), err
+	case TypeUnsignedSmallInt:
+		v, err := strconv.ParseUint(string(raw), 10, 16)
+		return uint16(v), err
+	case TypeUnsignedInt:
+		v, err := strconv.ParseUint(string(raw), 10, 32)
+		return uint32(v), err
+	case TypeUnsignedBigInt:
+		return strconv.ParseUint(string(raw), 10, 64)
+	case TypeFloat:
+		v, err := strconv.ParseFloat(string(raw), 32)
+		return float32(v), err
+	case TypeDouble:
+		return strconv.ParseFloat(string(raw), 64)
+	case TypeBinary, TypeNChar:
+		return decodeRawString(raw)
+	case TypeTimestamp:
+		return parseTimestamp(raw)
+	case TypeJSON:
+		// JSON 标签可能以对象或字符串形式返回
+		if raw[0] == '"' {
+			return decodeRawString(raw)
+		}
+		return string(raw), nil
+	case TypeVarBinary, TypeGeometry:
+		s, err := decodeRawString(raw)
+		if err != nil {
+			return nil, err
+		}
+		// 以十六进制返回，可能带 \x 前缀
+		s = strings.TrimPrefix(s, "\\x")
+		return hex.DecodeString(s)
+	}
+	var v interface{}
+	err := json.UnmarshalUseNumber(raw, &v)
+	return v, err
+}
+
+// decodeRawString returns the string without unescaping when it has no escape sequence.
+func decodeRawString(raw []byte) (string, error) {
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' && bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1]), nil
+	}
+	var s string
+	err := json.Unmarshal(raw, &s)
+	return s, err
+}
@@ -0,0 +1,97 @@
+package connector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/json"
+)
+
+func TestDecodeRawValue(t *testing.T) {
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	tests := []struct {
+		name    string
+		colType int
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "null", colType: TypeInt, raw: "null", want: nil},
+		{name: "empty", colType: TypeInt, raw: "", want: nil},
+		{name: "bool true", colType: TypeBool, raw: "true", want: true},
+		{name: "bool 1", colType: TypeBool, raw: "1", want: true},
+		{name: "bool false", colType: TypeBool, raw: "false", want: false},
+		{name: "bool 0", colType: TypeBool, raw: "0", want: false},
+		{name: "bool invalid", colType: TypeBool, raw: `"yes"`, wantErr: true},
+		{name: "tinyint", colType: TypeTinyInt, raw: "-128", want: int8(math.MinInt8)},
+		{name: "tinyint overflow", colType: TypeTinyInt, raw: "128", wantErr: true},
+		{name: "smallint", colType: TypeSmallInt, raw: "32767", want: int16(math.MaxInt16)},
+		{name: "smallint overflow", colType: TypeSmallInt, raw: "-32769", wantErr: true},
+		{name: "int", colType: TypeInt, raw: "2147483647", want: int32(math.MaxInt32)},
+		{name: "int overflow", colType: TypeInt, raw: "2147483648", wantErr: true},
+		{name: "bigint", colType: TypeBigInt, raw: "9223372036854775807", want: int64(math.MaxInt64)},
+		{name: "bigint overflow", colType: TypeBigInt, raw: "9223372036854775808", wantErr: true},
+		{name: "bigint fraction", colType: TypeBigInt, raw: "1.5", wantErr: true},
+		{name: "utinyint", colType: TypeUnsignedTinyInt, raw: "255", want: uint8(math.MaxUint8)},
+		{name: "utinyint overflow", colType: TypeUnsignedTinyInt, raw: "256", wantErr: true},
+		{name: "usmallint", colType: TypeUnsignedSmallInt, raw: "65535", want: uint16(math.MaxUint16)},
+		{name: "usmallint overflow", colType: TypeUnsignedSmallInt, raw: "65536", wantErr: true},
+		{name: "uint", colType: TypeUnsignedInt, raw: "4294967295", want: uint32(math.MaxUint32)},
+		{name: "uint negative", colType: TypeUnsignedInt, raw: "-1", wantErr: true},
+		{name: "ubigint", colType: TypeUnsignedBigInt, raw: "18446744073709551615", want: uint64(math.MaxUint64)},
+		{name: "ubigint overflow", colType: TypeUnsignedBigInt, raw: "18446744073709551616", wantErr: true},
+		{name: "float", colType: TypeFloat, raw: "1.5", want: float32(1.5)},
+		{name: "float invalid", colType: TypeFloat, raw: `"x"`, wantErr: true},
+		{name: "double", colType: TypeDouble, raw: "1e300", want: 1e300},
+		{name: "double overflow", colType: TypeDouble, raw: "1e400", wantErr: true},
+		{name: "binary", colType: TypeBinary, raw: `"abc"`, want: "abc"},
+		{name: "binary escaped", colType: TypeBinary, raw: `"a\"b中"`, want: "a\"b中"},
+		{name: "nchar", colType: TypeNChar, raw: `"中文"`, want: "中文"},
+		{name: "nchar invalid", colType: TypeNChar, raw: "1", wantErr: true},
+		{name: "timestamp", colType: TypeTimestamp, raw: `"2021-01-02T03:04:05.006+0000"`, want: ts},
+		{name: "timestamp malformed", colType: TypeTimestamp, raw: `"2021-01-02"`, wantErr: true},
+		{name: "json object", colType: TypeJSON, raw: `{"a":1}`, want: `{"a":1}`},
+		{name: "json string", colType: TypeJSON, raw: `"{\"a\":1}"`, want: `{"a":1}`},
+		{name: "varbinary", colType: TypeVarBinary, raw: `"616263"`, want: []byte("abc")},
+		{name: "varbinary prefixed", colType: TypeVarBinary, raw: `"\\x616263"`, want: []byte("abc")},
+		{name: "varbinary invalid", colType: TypeVarBinary, raw: `"zz"`, wantErr: true},
+		{name: "geometry", colType: TypeGeometry, raw: `"\\x0101"`, want: []byte{1, 1}},
+		{name: "geometry not a string", colType: TypeGeometry, raw: "1", wantErr: true},
+		{name: "unknown type", colType: 99, raw: "12", want: json.Number("12")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawValue(tt.colType, []byte(tt.raw), defaultTimestampParser)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColumnMeta(t *testing.T) {
+	columns, err := parseColumnMeta([][]interface{}{{"ts", float64(TypeTimestamp), float64(8)}, {"v", json.Number("4"), json.Number("4")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Column{{Name: "ts", Type: TypeTimestamp, Length: 8}, {Name: "v", Type: TypeInt, Length: 4}}
+	if !reflect.DeepEqual(columns, want) {
+		t.Fatalf("got %v, want %v", columns, want)
+	}
+	for _, meta := range [][]interface{}{{"ts", float64(9)}, {1, float64(9), float64(8)}, {"ts", "9", float64(8)}} {
+		if _, err := parseColumnMeta([][]interface{}{meta}); err == nil {
+			t.Fatalf("column_meta %v got no error", meta)
+		}
+	}
+}
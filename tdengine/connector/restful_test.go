@@ -0,0 +1,138 @@
+package connector
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+func TestRestfulRows(t *testing.T) {
+	const columnMeta = `"column_meta":[["ts",9,8],["v",4,4]]`
+	const data = `"data":[["2022-01-01T00:00:00.000+0000",1],["2022-01-01T00:00:01.000+0000",2]]`
+	tests := []struct {
+		name string
+		body string
+		rows []int32
+	}{
+		{name: "status first", body: `{"status":"succ","head":["ts","v"],` + columnMeta + `,` + data + `,"rows":2}`, rows: []int32{1, 2}},
+		{name: "data first", body: `{` + data + `,` + columnMeta + `,"status":"succ","rows":2}`, rows: []int32{1, 2}},
+		{name: "data before column_meta", body: `{"status":"succ",` + data + `,` + columnMeta + `}`, rows: []int32{1, 2}},
+		{name: "empty data", body: `{"status":"succ",` + columnMeta + `,"data":[],"rows":0}`, rows: nil},
+		{name: "no data", body: `{"status":"succ","rows":0}`, rows: nil},
+	}
+	parseTimestamp, err := newTimestampParser("utc", "ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := newRestfulRows(ioutil.NopCloser(strings.NewReader(tt.body)), "select", parseTimestamp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var got []int32
+			for rows.Next() {
+				var ts interface{}
+				var v int32
+				if err = rows.Scan(&ts, &v); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, v)
+			}
+			if err = rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.rows) {
+				t.Fatalf("got rows %v, want %v", got, tt.rows)
+			}
+			for i := range got {
+				if got[i] != tt.rows[i] {
+					t.Fatalf("got rows %v, want %v", got, tt.rows)
+				}
+			}
+		})
+	}
+}
+
+func TestRestfulRowsError(t *testing.T) {
+	parseTimestamp, err := newTimestampParser("utc", "ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"data":[[1]],"status":"error","code":866,"desc":"Table does not exist"}`
+	_, err = newRestfulRows(ioutil.NopCloser(strings.NewReader(body)), "select", parseTimestamp)
+	if err == nil || !strings.Contains(err.Error(), "Table does not exist") {
+		t.Fatalf("got error %v, want the TDengine error", err)
+	}
+}
+
+func TestJoinOpenTSDBJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+		err   bool
+	}{
+		{name: "single", lines: []string{`{"metric":"a"}`}, want: `{"metric":"a"}`},
+		{name: "objects", lines: []string{`{"metric":"a"}`, ` {"metric":"b"} `}, want: `[{"metric":"a"},{"metric":"b"}]`},
+		{name: "arrays", lines: []string{`[{"metric":"a"},{"metric":"b"}]`, `{"metric":"c"}`}, want: `[{"metric":"a"},{"metric":"b"},{"metric":"c"}]`},
+		{name: "invalid", lines: []string{`{"metric":"a"}`, `{"metric":`}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := joinOpenTSDBJSON(tt.lines)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestfulSchemalessOpenTSDBJSON(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	conf := &config.TDengineRestful{Address: server.URL}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewRestfulConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connector.Close()
+	err = connector.SchemalessWrite(context.Background(), "db", common.OpenTSDBJSONProtocol, []string{`{"metric":"a","value":1}`, `{"metric":"b","value":2}`}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/opentsdb/v1/put/json/db" || body != `[{"metric":"a","value":1},{"metric":"b","value":2}]` {
+		t.Fatalf("got %s %s, want one JSON array", path, body)
+	}
+}
+
+func TestNewRestfulTransportDefaults(t *testing.T) {
+	rt, err := newRestfulTransport(&config.TDengineRestful{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := rt.(*http.Transport)
+	if transport.TLSHandshakeTimeout != 10*time.Second || transport.MaxIdleConns != 100 || transport.IdleConnTimeout != 90*time.Second {
+		t.Fatalf("got TLS handshake timeout %s, %d idle connections and idle timeout %s, want 10s, 100 and 90s",
+			transport.TLSHandshakeTimeout, transport.MaxIdleConns, transport.IdleConnTimeout)
+	}
+}
@@ -0,0 +1,70 @@
+package connector
+
+import (
+	"fmt"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"strconv"
+	"time"
+)
+
+// /rest/sql 返回服务端本地时间，没有时区
+const LocalLayout = "2006-01-02 15:04:05.999999999"
+
+// timestampParser converts a TIMESTAMP cell, the cell is a JSON string or number depending on the endpoint.
+type timestampParser func(raw []byte) (time.Time, error)
+
+// restfulSQLPath returns the endpoint of format, which is one of common.UTCTimestampFormat,
+// common.StringTimestampFormat and common.EpochTimestampFormat.
+func restfulSQLPath(format string) (string, error) {
+	switch format {
+	case "", common.UTCTimestampFormat:
+		return "/rest/sqlutc", nil
+	case common.StringTimestampFormat:
+		return "/rest/sql", nil
+	case common.EpochTimestampFormat:
+		return "/rest/sqlt", nil
+	}
+	return "", fmt.Errorf("unsupported timestamp format %s", format)
+}
+
+// newTimestampParser returns the parser of format, precision is ms, us or ns and is only needed for epoch timestamps.
+func newTimestampParser(format string, precision string) (timestampParser, error) {
+	switch format {
+	case "", common.UTCTimestampFormat:
+		return parseLayoutTimestamp(Layout, time.UTC), nil
+	case common.StringTimestampFormat:
+		return parseLayoutTimestamp(LocalLayout, time.Local), nil
+	case common.EpochTimestampFormat:
+		var code int
+		switch precision {
+		case "", "ms":
+			code = 0
+		case "us":
+			code = 1
+		case "ns":
+			code = 2
+		default:
+			return nil, fmt.Errorf("unsupported precision %s", precision)
+		}
+		return func(raw []byte) (time.Time, error) {
+			ts, err := strconv.ParseInt(string(raw), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid epoch timestamp %s", raw)
+			}
+			return timestampToTime(ts, code), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported timestamp format %s", format)
+}
+
+func parseLayoutTimestamp(layout string, location *time.Location) timestampParser {
+	return func(raw []byte) (time.Time, error) {
+		s, err := decodeRawString(raw)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.ParseInLocation(layout, s, location)
+	}
+}
+
+var defaultTimestampParser = parseLayoutTimestamp(Layout, time.UTC)
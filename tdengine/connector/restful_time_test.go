@@ -0,0 +1,83 @@
+package connector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+)
+
+func TestRestfulSQLPath(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "", want: "/rest/sqlutc"},
+		{format: common.UTCTimestampFormat, want: "/rest/sqlutc"},
+		{format: common.StringTimestampFormat, want: "/rest/sql"},
+		{format: common.EpochTimestampFormat, want: "/rest/sqlt"},
+		{format: "rfc3339", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := restfulSQLPath(tt.format)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("format %q got %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestTimestampParser(t *testing.T) {
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 6007008, time.UTC)
+	tests := []struct {
+		name      string
+		format    string
+		precision string
+		raw       string
+		want      time.Time
+		wantErr   bool
+	}{
+		{name: "utc", format: common.UTCTimestampFormat, raw: `"2021-01-02T11:04:05.006007008+0800"`, want: ts},
+		{name: "utc default", raw: `"2021-01-02T03:04:05.006007008+0000"`, want: ts},
+		{name: "utc malformed", format: common.UTCTimestampFormat, raw: `"2021-01-02 03:04:05"`, wantErr: true},
+		{name: "utc not a string", format: common.UTCTimestampFormat, raw: "1609556645006", wantErr: true},
+		{name: "string", format: common.StringTimestampFormat, raw: `"2021-01-02 03:04:05.006007008"`,
+			want: time.Date(2021, 1, 2, 3, 4, 5, 6007008, time.Local)},
+		{name: "string malformed", format: common.StringTimestampFormat, raw: `"2021-01-02T03:04:05Z"`, wantErr: true},
+		{name: "epoch ms", format: common.EpochTimestampFormat, raw: "1609556645006", want: ts.Truncate(time.Millisecond)},
+		{name: "epoch us", format: common.EpochTimestampFormat, precision: "us", raw: "1609556645006007", want: ts.Truncate(time.Microsecond)},
+		{name: "epoch ns", format: common.EpochTimestampFormat, precision: "ns", raw: "1609556645006007008", want: ts},
+		{name: "epoch malformed", format: common.EpochTimestampFormat, raw: `"1609556645006"`, wantErr: true},
+		{name: "epoch overflow", format: common.EpochTimestampFormat, raw: "9223372036854775808", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse, err := newTimestampParser(tt.format, tt.precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parse([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTimestampParserUnsupported(t *testing.T) {
+	if _, err := newTimestampParser(common.EpochTimestampFormat, "s"); err == nil {
+		t.Fatal("unsupported precision got no error")
+	}
+	if _, err := newTimestampParser("rfc3339", ""); err == nil {
+		t.Fatal("unsupported format got no error")
+	}
+}
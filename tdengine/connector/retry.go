@@ -0,0 +1,103 @@
+package connector
+
+import (
+	"context"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// retrier retries requests failed with retriable errors using exponential backoff with full jitter.
+type retrier struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	budget         *retryBudget
+}
+
+func newRetrier(conf *config.Retry) *retrier {
+	maxAttempts := conf.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &retrier{
+		maxAttempts:    maxAttempts,
+		initialBackoff: conf.InitialBackoff,
+		maxBackoff:     conf.MaxBackoff,
+		budget:         newRetryBudget(conf.BudgetTokens, conf.BudgetRatio),
+	}
+}
+
+// do calls fn until it succeeds, fails with a fatal error, runs out of attempts or budget,
+// or the next backoff would exceed the deadline of ctx. The last error of fn is returned.
+func (r *retrier) do(ctx context.Context, fn func() error) error {
+	r.budget.deposit()
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || attempt+1 >= r.maxAttempts || !common.IsRetriable(err) {
+			return err
+		}
+		backoff := r.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+		if !r.budget.withdraw() {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (r *retrier) backoff(attempt int) time.Duration {
+	backoff := r.maxBackoff
+	if attempt < 32 {
+		if b := r.initialBackoff << uint(attempt); b > 0 && b < backoff {
+			backoff = b
+		}
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryBudget is a token bucket shared by all requests of a connector,
+// it stops retry storms when most requests are failing.
+type retryBudget struct {
+	lock      sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+func newRetryBudget(maxTokens int, ratio float64) *retryBudget {
+	return &retryBudget{tokens: float64(maxTokens), maxTokens: float64(maxTokens), ratio: ratio}
+}
+
+func (b *retryBudget) deposit() {
+	b.lock.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+	b.lock.Unlock()
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,91 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+var (
+	errRetriable = &common.HTTPStatusError{StatusCode: 503, Body: "unavailable"}
+	errFatal     = &common.TDengineError{Code: 0x2600, Desc: "syntax error"}
+)
+
+func TestRetrierDo(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name      string
+		conf      config.Retry
+		ctx       context.Context
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", conf: config.Retry{MaxAttempts: 3, BudgetTokens: 10}, errs: []error{nil}, wantCalls: 1},
+		{name: "retried", conf: config.Retry{MaxAttempts: 3, BudgetTokens: 10}, errs: []error{errRetriable, errRetriable, nil}, wantCalls: 3},
+		{name: "fatal", conf: config.Retry{MaxAttempts: 3, BudgetTokens: 10}, errs: []error{errFatal, nil}, wantCalls: 1, wantErr: errFatal},
+		{name: "attempts", conf: config.Retry{MaxAttempts: 2, BudgetTokens: 10}, errs: []error{errRetriable, errRetriable, nil}, wantCalls: 2, wantErr: errRetriable},
+		{name: "no budget", conf: config.Retry{MaxAttempts: 3}, errs: []error{errRetriable, nil}, wantCalls: 1, wantErr: errRetriable},
+		{name: "canceled", conf: config.Retry{MaxAttempts: 3, BudgetTokens: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+			ctx: canceled, errs: []error{errRetriable, nil}, wantCalls: 1, wantErr: errRetriable},
+		{name: "deadline", conf: config.Retry{MaxAttempts: 3, BudgetTokens: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+			errs: []error{errRetriable, nil}, wantCalls: 1, wantErr: errRetriable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+			}
+			r := newRetrier(&tt.conf)
+			calls := 0
+			err := r.do(ctx, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if calls != tt.wantCalls || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %d calls and %v, want %d calls and %v", calls, err, tt.wantCalls, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetrierBudget(t *testing.T) {
+	r := newRetrier(&config.Retry{MaxAttempts: 5, BudgetTokens: 2, BudgetRatio: 0.5})
+	var calls int
+	fail := func() error {
+		calls++
+		return errRetriable
+	}
+	// 第一次请求用掉 2 个 token 重试 2 次，之后每次请求归还 0.5 个，第三次请求时才够重试 1 次
+	for i, want := range []int{3, 1, 2} {
+		calls = 0
+		_ = r.do(context.Background(), fail)
+		if calls != want {
+			t.Fatalf("request %d made %d calls, want %d", i, calls, want)
+		}
+	}
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	r := newRetrier(&config.Retry{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt := 0; attempt < 40; attempt++ {
+		limit := time.Second
+		if attempt < 4 {
+			limit = 100 * time.Millisecond << uint(attempt)
+		}
+		for i := 0; i < 20; i++ {
+			if backoff := r.backoff(attempt); backoff < 0 || backoff > limit {
+				t.Fatalf("attempt %d backed off %s, want at most %s", attempt, backoff, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package connector
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errNoRow = errors.New("scan called without calling next")
+
+// scanRow copies the values of the current row into dest.
+// dest may be *interface{}, a sql.Scanner or a pointer to a type the value is assignable or convertible to.
+func scanRow(values []interface{}, dest []interface{}) error {
+	if values == nil {
+		return errNoRow
+	}
+	if len(dest) != len(values) {
+		return fmt.Errorf("expected %d destination arguments in scan, not %d", len(values), len(dest))
+	}
+	for i, value := range values {
+		if err := convertAssign(dest[i], value); err != nil {
+			return fmt.Errorf("scan error on column index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func convertAssign(dest interface{}, src interface{}) error {
+	switch d := dest.(type) {
+	case *interface{}:
+		*d = src
+		return nil
+	case sql.Scanner:
+		return d.Scan(src)
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return errors.New("destination not a pointer")
+	}
+	dv = dv.Elem()
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+	if isNumberKind(sv.Kind()) && isNumberKind(dv.Kind()) {
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	}
+	if s, ok := src.(string); ok && dv.Kind() == reflect.Slice && dv.Type().Elem().Kind() == reflect.Uint8 {
+		dv.SetBytes([]byte(s))
+		return nil
+	}
+	return fmt.Errorf("unsupported scan, storing %T into type %s", src, dv.Type())
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// NewDataRows returns Rows reading an in-memory result, it is useful for connectors wrapping another one and for tests.
+func NewDataRows(data *Data) Rows {
+	return &dataRows{data: data, index: -1}
+}
+
+type dataRows struct {
+	data   *Data
+	index  int
+	closed bool
+}
+
+func (r *dataRows) Columns() []string {
+	return r.data.Head
+}
+
+func (r *dataRows) ColumnTypes() []*Column {
+	return r.data.Columns
+}
+
+func (r *dataRows) Next() bool {
+	if r.closed || r.index+1 >= len(r.data.Data) {
+		r.index = len(r.data.Data)
+		return false
+	}
+	r.index++
+	return true
+}
+
+func (r *dataRows) Scan(dest ...interface{}) error {
+	if r.closed || r.index < 0 || r.index >= len(r.data.Data) {
+		return errNoRow
+	}
+	return scanRow(r.data.Data[r.index], dest)
+}
+
+func (r *dataRows) Err() error {
+	return nil
+}
+
+func (r *dataRows) Close() error {
+	r.closed = true
+	return nil
+}
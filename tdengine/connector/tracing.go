@@ -0,0 +1,34 @@
+package connector
+
+import (
+	"context"
+	"github.com/taosdata/go-utils/tdengine/tracing"
+)
+
+// TracingInterceptor creates an OpenTelemetry span for every round trip to TDengine,
+// register it with RegisterInterceptor(TracingInterceptor()) to trace every connector.
+// Spans carry the SQL hash, the rows returned or affected and the error code, never the SQL values.
+func TracingInterceptor() Interceptor {
+	return func(next TDengineConnector) TDengineConnector {
+		connectorType := ConnectorType(next)
+		return Intercept(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			ctx, span := tracing.Start(ctx, "tdengine."+call.Method,
+				tracing.ConnectorKey.String(connectorType),
+				tracing.DBOperationKey.String(call.Method),
+				tracing.SQLHashKey.String(tracing.SQLHash(call.SQL)),
+			)
+			err := invoke(ctx)
+			if err == nil {
+				if call.Data != nil {
+					span.SetAttributes(tracing.RowsKey.Int(len(call.Data.Data)))
+				} else if call.Lines != nil {
+					span.SetAttributes(tracing.RowsKey.Int(len(call.Lines)))
+				} else {
+					span.SetAttributes(tracing.AffectedKey.Int64(call.Affected))
+				}
+			}
+			tracing.End(span, err)
+			return err
+		})(next)
+	}
+}
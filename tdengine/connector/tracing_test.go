@@ -0,0 +1,64 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failingConnector fails every Exec with a TDengine error.
+type failingConnector struct {
+	rowsConnector
+}
+
+func (failingConnector) Exec(ctx context.Context, sql string) (int64, error) {
+	return 0, &common.TDengineError{Code: 0x2662, Desc: "Table does not exist"}
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	ctx := context.Background()
+	if _, err := Chain(&sqlConnector{}, TracingInterceptor()).Exec(ctx, "insert into t values(now, 1)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Chain(failingConnector{}, TracingInterceptor()).Exec(ctx, "drop table t"); err == nil {
+		t.Fatal("Exec succeeded")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if span.Name() != "tdengine.Exec" || span.SpanKind() != trace.SpanKindClient {
+			t.Fatalf("got span %s of kind %s", span.Name(), span.SpanKind())
+		}
+	}
+	ok, failed := spanAttributes(spans[0]), spanAttributes(spans[1])
+	if ok[tracing.SQLHashKey].AsString() != tracing.SQLHash("insert into t values(now, 1)") || ok[tracing.AffectedKey].AsInt64() != 1 ||
+		ok[tracing.ConnectorKey].AsString() != "*connector.sqlConnector" {
+		t.Fatalf("got attributes %v", spans[0].Attributes())
+	}
+	if failed[tracing.ErrorCodeKey].AsInt64() != 0x2662 || spans[1].Status().Code != codes.Error {
+		t.Fatalf("got attributes %v and status %v", spans[1].Attributes(), spans[1].Status())
+	}
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
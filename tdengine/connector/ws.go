@@ -0,0 +1,548 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// WebSocketConnector talks to the taosAdapter WebSocket SQL endpoint (/rest/ws), it is pure Go and needs no cgo.
+type WebSocketConnector struct {
+	url      string
+	username string
+	password string
+	database string
+	dialer   *websocket.Dialer
+	idle     chan *wsConn
+	// maxIdleTime 之后空闲连接不再复用
+	maxIdleTime time.Duration
+	reqID       uint64
+	closed      int32
+}
+
+type wsRequest struct {
+	Action string      `json:"action"`
+	Args   interface{} `json:"args"`
+}
+
+type wsConnArgs struct {
+	ReqID    uint64 `json:"req_id"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	DB       string `json:"db,omitempty"`
+}
+
+type wsQueryArgs struct {
+	ReqID uint64 `json:"req_id"`
+	Sql   string `json:"sql"`
+}
+
+type wsResultArgs struct {
+	ReqID uint64 `json:"req_id"`
+	ID    uint64 `json:"id"`
+}
+
+type wsResp struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Action  string `json:"action"`
+	ReqID   uint64 `json:"req_id"`
+}
+
+type wsQueryResp struct {
+	wsResp
+	ID            uint64   `json:"id"`
+	IsUpdate      bool     `json:"is_update"`
+	AffectedRows  int64    `json:"affected_rows"`
+	FieldsCount   int      `json:"fields_count"`
+	FieldsNames   []string `json:"fields_names"`
+	FieldsTypes   []int    `json:"fields_types"`
+	FieldsLengths []int64  `json:"fields_lengths"`
+	Precision     int      `json:"precision"`
+}
+
+type wsFetchResp struct {
+	wsResp
+	ID        uint64 `json:"id"`
+	Completed bool   `json:"completed"`
+	Lengths   []int  `json:"lengths"`
+	Rows      int    `json:"rows"`
+}
+
+func (r *wsResp) error() error {
+	if r.Code == 0 {
+		return nil
+	}
+	return &common.TDengineError{Code: r.Code, Desc: r.Message}
+}
+
+func NewWebSocketConnector(conf *config.TDengineWebSocket) (*WebSocketConnector, error) {
+	u, err := url.Parse(conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/rest/ws")
+	maxIdle := conf.MaxIdle
+	if maxIdle < 0 {
+		maxIdle = 0
+	}
+	maxIdleTime := conf.MaxIdleTime
+	if maxIdleTime <= 0 {
+		maxIdleTime = wsMaxIdleTime
+	}
+	connector := &WebSocketConnector{
+		url:      u.String(),
+		username: conf.Username,
+		password: conf.Password,
+		database: conf.Database,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 10 * time.Second,
+		},
+		idle:        make(chan *wsConn, maxIdle),
+		maxIdleTime: maxIdleTime,
+	}
+	// 建立一个连接校验地址和用户名密码
+	conn, _, err := connector.getConn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	connector.putConn(conn)
+	return connector, nil
+}
+
+func (w *WebSocketConnector) Exec(ctx context.Context, sql string) (int64, error) {
+	conn, stop, resp, err := w.startQuery(ctx, sql)
+	if err != nil {
+		return 0, err
+	}
+	defer w.putConn(conn)
+	defer stop()
+	if !resp.IsUpdate {
+		w.freeResult(conn, resp.ID)
+	}
+	return resp.AffectedRows, nil
+}
+
+func (w *WebSocketConnector) Query(ctx context.Context, sql string) (*Data, error) {
+	rows, err := w.queryRows(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := &Data{Head: rows.columns, Columns: rows.columnTypes}
+	for rows.Next() {
+		result.Data = append(result.Data, rows.values)
+	}
+	if rows.err != nil {
+		return nil, rows.err
+	}
+	return result, nil
+}
+
+func (w *WebSocketConnector) QueryRows(ctx context.Context, sql string) (Rows, error) {
+	rows, err := w.queryRows(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Prepare returns a statement whose args are interpolated on the client side, see ExecArgs.
+func (w *WebSocketConnector) Prepare(ctx context.Context, sql string) (Stmt, error) {
+	return newInterpolatedStmt(w, sql), nil
+}
+
+// ExecArgs interpolates args into sql as escaped literals since the endpoint has no parameter binding.
+func (w *WebSocketConnector) ExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	sql, err := interpolateParams(sql, args)
+	if err != nil {
+		return 0, err
+	}
+	return w.Exec(ctx, sql)
+}
+
+// QueryArgs interpolates args into sql as escaped literals since the endpoint has no parameter binding.
+func (w *WebSocketConnector) QueryArgs(ctx context.Context, sql string, args ...interface{}) (*Data, error) {
+	sql, err := interpolateParams(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return w.Query(ctx, sql)
+}
+
+func (w *WebSocketConnector) queryRows(ctx context.Context, sql string) (*wsRows, error) {
+	conn, stop, resp, err := w.startQuery(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	rows := &wsRows{
+		w:         w,
+		conn:      conn,
+		stop:      stop,
+		id:        resp.ID,
+		columns:   resp.FieldsNames,
+		types:     resp.FieldsTypes,
+		precision: resp.Precision,
+		completed: resp.IsUpdate,
+	}
+	if rows.columns == nil {
+		rows.columns = []string{}
+	}
+	rows.columnTypes = make([]*Column, len(rows.columns))
+	for i, name := range rows.columns {
+		column := &Column{Name: name}
+		if i < len(resp.FieldsTypes) {
+			column.Type = resp.FieldsTypes[i]
+		}
+		if i < len(resp.FieldsLengths) {
+			column.Length = int(resp.FieldsLengths[i])
+		}
+		rows.columnTypes[i] = column
+	}
+	return rows, nil
+}
+
+// startQuery sends sql on a connection watched by ctx, the caller calls stop and puts conn back once done.
+// An idle connection closed by the server is dropped and the query is sent again on the next one, or on a new connection.
+func (w *WebSocketConnector) startQuery(ctx context.Context, sql string) (conn *wsConn, stop func(), resp *wsQueryResp, err error) {
+	for {
+		var reused bool
+		conn, reused, err = w.getConn(ctx)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		stop = conn.watch(ctx)
+		resp, err = w.query(conn, sql)
+		if err == nil {
+			return conn, stop, resp, nil
+		}
+		stop()
+		w.putConn(conn)
+		if !reused || ctx.Err() != nil || !isClosedConnError(err) {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+// isClosedConnError reports whether err means the peer closed the connection, so the request was not processed.
+func isClosedConnError(err error) bool {
+	var closeError *websocket.CloseError
+	return errors.As(err, &closeError) || errors.Is(err, websocket.ErrCloseSent) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+func (w *WebSocketConnector) query(conn *wsConn, sql string) (*wsQueryResp, error) {
+	var resp wsQueryResp
+	err := conn.call("query", &wsQueryArgs{ReqID: w.nextReqID(), Sql: sql}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err = resp.error(); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (w *WebSocketConnector) freeResult(conn *wsConn, id uint64) {
+	// free_result 没有响应
+	_ = conn.send("free_result", &wsResultArgs{ReqID: w.nextReqID(), ID: id})
+}
+
+func (w *WebSocketConnector) nextReqID() uint64 {
+	return atomic.AddUint64(&w.reqID, 1)
+}
+
+// getConn returns an idle connection, or dials a new one when there is none, reused reports which.
+// Connections idle for longer than MaxIdleTime are closed instead of being reused.
+func (w *WebSocketConnector) getConn(ctx context.Context) (conn *wsConn, reused bool, err error) {
+	if atomic.LoadInt32(&w.closed) != 0 {
+		return nil, false, errConnectorClosed
+	}
+	for {
+		select {
+		case conn = <-w.idle:
+			if time.Since(conn.idleSince) < w.maxIdleTime {
+				return conn, true, nil
+			}
+			conn.conn.Close()
+		default:
+			conn, err = w.dial(ctx)
+			return conn, false, err
+		}
+	}
+}
+
+func (w *WebSocketConnector) dial(ctx context.Context) (*wsConn, error) {
+	c, _, err := w.dialer.DialContext(ctx, w.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn := &wsConn{conn: c}
+	stop := conn.watch(ctx)
+	defer stop()
+	var resp wsResp
+	err = conn.call("conn", &wsConnArgs{ReqID: w.nextReqID(), User: w.username, Password: w.password, DB: w.database}, &resp)
+	if err == nil {
+		err = resp.error()
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// putConn returns conn to the idle pool, broken connections, connections over MaxIdle
+// and the connections of a closed connector are closed.
+func (w *WebSocketConnector) putConn(conn *wsConn) {
+	if conn.broken || atomic.LoadInt32(&w.closed) != 0 {
+		conn.conn.Close()
+		return
+	}
+	conn.idleSince = time.Now()
+	select {
+	case w.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+	// Close 可能在放回前清空了空闲连接
+	if atomic.LoadInt32(&w.closed) != 0 {
+		w.closeIdle()
+	}
+}
+
+var errConnectorClosed = errors.New("connector is closed")
+
+// Close closes the idle connections, the ones in use are closed when their call is done or their rows are closed.
+// The connector returns an error for every call afterwards.
+func (w *WebSocketConnector) Close() error {
+	if atomic.CompareAndSwapInt32(&w.closed, 0, 1) {
+		w.closeIdle()
+	}
+	return nil
+}
+
+func (w *WebSocketConnector) closeIdle() {
+	for {
+		select {
+		case conn := <-w.idle:
+			conn.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// wsConn is used by one request at a time, it is taken from the idle pool and put back when the request is done.
+type wsConn struct {
+	conn      *websocket.Conn
+	broken    bool
+	idleSince time.Time
+}
+
+// wsMaxIdleTime 为 MaxIdleTime 未设置时的默认值
+const wsMaxIdleTime = 5 * time.Minute
+
+var aLongTimeAgo = time.Unix(1, 0)
+
+// watch applies the deadline of ctx to the connection and interrupts blocked reads and writes when ctx is done.
+func (c *wsConn) watch(ctx context.Context) (stop func()) {
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetReadDeadline(deadline)
+	_ = c.conn.SetWriteDeadline(deadline)
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			_ = c.conn.SetReadDeadline(aLongTimeAgo)
+			_ = c.conn.SetWriteDeadline(aLongTimeAgo)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+		if ctx.Err() != nil {
+			c.broken = true
+		}
+	}
+}
+
+func (c *wsConn) send(action string, args interface{}) error {
+	b, err := json.Marshal(&wsRequest{Action: action, Args: args})
+	if err != nil {
+		return err
+	}
+	if err = c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		c.broken = true
+		return err
+	}
+	return nil
+}
+
+func (c *wsConn) call(action string, args interface{}, resp interface{}) error {
+	if err := c.send(action, args); err != nil {
+		return err
+	}
+	messageType, message, err := c.read()
+	if err != nil {
+		return err
+	}
+	if messageType != websocket.TextMessage {
+		c.broken = true
+		return fmt.Errorf("unexpected binary message for action %s", action)
+	}
+	return json.Unmarshal(message, resp)
+}
+
+func (c *wsConn) callBinary(action string, args interface{}) ([]byte, error) {
+	if err := c.send(action, args); err != nil {
+		return nil, err
+	}
+	messageType, message, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	if messageType != websocket.BinaryMessage {
+		var resp wsResp
+		if err = json.Unmarshal(message, &resp); err == nil && resp.error() != nil {
+			return nil, resp.error()
+		}
+		c.broken = true
+		return nil, fmt.Errorf("unexpected text message for action %s: %s", action, message)
+	}
+	return message, nil
+}
+
+func (c *wsConn) read() (int, []byte, error) {
+	messageType, message, err := c.conn.ReadMessage()
+	if err != nil {
+		c.broken = true
+		return 0, nil, err
+	}
+	return messageType, message, nil
+}
+
+// wsRows holds its connection until closed and fetches one block at a time.
+type wsRows struct {
+	w           *WebSocketConnector
+	conn        *wsConn
+	stop        func()
+	id          uint64
+	columns     []string
+	columnTypes []*Column
+	types       []int
+	precision   int
+	block       [][]interface{}
+	blockIndex  int
+	values      []interface{}
+	completed   bool
+	err         error
+	closed      bool
+}
+
+// fetch_block 返回的二进制消息前 16 字节为 timing 和结果集 id
+const wsBlockPrefixSize = 16
+
+var errWebSocketClosedRows = errors.New("rows are closed")
+
+func (r *wsRows) Columns() []string {
+	return r.columns
+}
+
+func (r *wsRows) ColumnTypes() []*Column {
+	return r.columnTypes
+}
+
+func (r *wsRows) Next() bool {
+	r.values = nil
+	if r.err != nil || r.closed {
+		return false
+	}
+	for r.blockIndex >= len(r.block) {
+		if r.completed {
+			return false
+		}
+		if err := r.fetch(); err != nil {
+			r.err = err
+			return false
+		}
+	}
+	r.values = r.block[r.blockIndex]
+	r.blockIndex++
+	return true
+}
+
+func (r *wsRows) fetch() error {
+	var resp wsFetchResp
+	err := r.conn.call("fetch", &wsResultArgs{ReqID: r.w.nextReqID(), ID: r.id}, &resp)
+	if err != nil {
+		return err
+	}
+	if err = resp.error(); err != nil {
+		return err
+	}
+	r.block = nil
+	r.blockIndex = 0
+	if resp.Completed {
+		r.completed = true
+		return nil
+	}
+	message, err := r.conn.callBinary("fetch_block", &wsResultArgs{ReqID: r.w.nextReqID(), ID: r.id})
+	if err != nil {
+		return err
+	}
+	if len(message) < wsBlockPrefixSize {
+		r.conn.broken = true
+		return errShortBlock
+	}
+	r.block, err = parseRawBlock(message[wsBlockPrefixSize:], r.types, r.precision)
+	return err
+}
+
+func (r *wsRows) Scan(dest ...interface{}) error {
+	if r.closed {
+		return errWebSocketClosedRows
+	}
+	return scanRow(r.values, dest)
+}
+
+func (r *wsRows) Err() error {
+	return r.err
+}
+
+func (r *wsRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.values = nil
+	r.block = nil
+	if !r.conn.broken {
+		r.w.freeResult(r.conn, r.id)
+	}
+	r.stop()
+	r.w.putConn(r.conn)
+	return nil
+}
+
+func (w *WebSocketConnector) connectorType() string {
+	return common.TDengineWebSocketConnectorType
+}
@@ -0,0 +1,166 @@
+package connector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// raw block 头部: version int32, length int32, rows int32, cols int32, flagSegment int32, groupId uint64
+const rawBlockHeaderSize = 28
+
+var errShortBlock = errors.New("raw block is truncated")
+
+// parseRawBlock decodes a TDengine raw block into rows.
+// Each column is laid out after the header, the column schemas and the column lengths:
+// variable length columns start with one int32 offset per row (-1 for NULL) followed by uint16 length prefixed values,
+// fixed length columns start with a NULL bitmap followed by the values.
+func parseRawBlock(block []byte, types []int, precision int) ([][]interface{}, error) {
+	if len(block) < rawBlockHeaderSize {
+		return nil, errShortBlock
+	}
+	rows := int(int32(binary.LittleEndian.Uint32(block[8:])))
+	cols := int(int32(binary.LittleEndian.Uint32(block[12:])))
+	if rows < 0 || cols != len(types) {
+		return nil, fmt.Errorf("raw block has %d rows and %d columns, expected %d columns", rows, cols, len(types))
+	}
+	offset := rawBlockHeaderSize + cols*5
+	if len(block) < offset+cols*4 {
+		return nil, errShortBlock
+	}
+	lengths := make([]int, cols)
+	for i := range lengths {
+		lengths[i] = int(int32(binary.LittleEndian.Uint32(block[offset:])))
+		offset += 4
+	}
+	result := make([][]interface{}, rows)
+	for i := range result {
+		result[i] = make([]interface{}, cols)
+	}
+	for col, colType := range types {
+		if isVarDataType(colType) {
+			if len(block) < offset+rows*4+lengths[col] {
+				return nil, errShortBlock
+			}
+			offsets := block[offset : offset+rows*4]
+			offset += rows * 4
+			data := block[offset : offset+lengths[col]]
+			for row := 0; row < rows; row++ {
+				start := int(int32(binary.LittleEndian.Uint32(offsets[row*4:])))
+				if start < 0 {
+					continue
+				}
+				if len(data) < start+2 {
+					return nil, errShortBlock
+				}
+				end := start + 2 + int(binary.LittleEndian.Uint16(data[start:]))
+				if len(data) < end {
+					return nil, errShortBlock
+				}
+				result[row][col] = convertVarData(colType, data[start+2:end])
+			}
+		} else {
+			size := fixedDataSize(colType)
+			if size == 0 {
+				return nil, fmt.Errorf("unsupported column type %d in raw block", colType)
+			}
+			bitmapLength := (rows + 7) / 8
+			if len(block) < offset+bitmapLength+lengths[col] || lengths[col] < rows*size {
+				return nil, errShortBlock
+			}
+			bitmap := block[offset : offset+bitmapLength]
+			offset += bitmapLength
+			data := block[offset : offset+lengths[col]]
+			for row := 0; row < rows; row++ {
+				if bitmap[row>>3]&(1<<(7-uint(row&7))) != 0 {
+					continue
+				}
+				result[row][col] = convertFixedData(colType, data[row*size:], precision)
+			}
+		}
+		offset += lengths[col]
+	}
+	return result, nil
+}
+
+func isVarDataType(colType int) bool {
+	switch colType {
+	case TypeBinary, TypeNChar, TypeJSON, TypeVarBinary, TypeGeometry:
+		return true
+	}
+	return false
+}
+
+func fixedDataSize(colType int) int {
+	switch colType {
+	case TypeBool, TypeTinyInt, TypeUnsignedTinyInt:
+		return 1
+	case TypeSmallInt, TypeUnsignedSmallInt:
+		return 2
+	case TypeInt, TypeFloat, TypeUnsignedInt:
+		return 4
+	case TypeBigInt, TypeDouble, TypeTimestamp, TypeUnsignedBigInt:
+		return 8
+	}
+	return 0
+}
+
+func convertVarData(colType int, data []byte) interface{} {
+	switch colType {
+	case TypeNChar:
+		// NCHAR 以 UCS-4 编码
+		runes := make([]rune, len(data)/4)
+		for i := range runes {
+			runes[i] = rune(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return string(runes)
+	case TypeVarBinary, TypeGeometry:
+		return append([]byte(nil), data...)
+	default:
+		return string(data)
+	}
+}
+
+func convertFixedData(colType int, data []byte, precision int) interface{} {
+	switch colType {
+	case TypeBool:
+		return data[0] != 0
+	case TypeTinyInt:
+		return int8(data[0])
+	case TypeSmallInt:
+		return int16(binary.LittleEndian.Uint16(data))
+	case TypeInt:
+		return int32(binary.LittleEndian.Uint32(data))
+	case TypeBigInt:
+		return int64(binary.LittleEndian.Uint64(data))
+	case TypeFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	case TypeDouble:
+		return math.Float64frombits(binary.LittleEndian.Uint64(data))
+	case TypeTimestamp:
+		return timestampToTime(int64(binary.LittleEndian.Uint64(data)), precision)
+	case TypeUnsignedTinyInt:
+		return data[0]
+	case TypeUnsignedSmallInt:
+		return binary.LittleEndian.Uint16(data)
+	case TypeUnsignedInt:
+		return binary.LittleEndian.Uint32(data)
+	case TypeUnsignedBigInt:
+		return binary.LittleEndian.Uint64(data)
+	}
+	return nil
+}
+
+// timestampToTime converts an epoch timestamp, precision 0 is millisecond, 1 is microsecond and 2 is nanosecond.
+func timestampToTime(ts int64, precision int) time.Time {
+	switch precision {
+	case 1:
+		return time.Unix(0, ts*int64(time.Microsecond))
+	case 2:
+		return time.Unix(0, ts)
+	default:
+		return time.Unix(0, ts*int64(time.Millisecond))
+	}
+}
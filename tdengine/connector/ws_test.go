@@ -0,0 +1,268 @@
+package connector
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/taosdata/go-utils/json"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/config"
+)
+
+// wsServer is a stand-in for the taosAdapter WebSocket SQL endpoint.
+// select returns one INT column v with the rows 1 and 2, insert affects 2 rows, anything else is a TDengine error.
+type wsServer struct {
+	*httptest.Server
+	lock  sync.Mutex
+	conns []*websocket.Conn
+	dials int
+}
+
+func newWSServer(t *testing.T) *wsServer {
+	s := &wsServer{}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.lock.Lock()
+		s.conns = append(s.conns, c)
+		s.dials++
+		s.lock.Unlock()
+		s.serve(c)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *wsServer) serve(c *websocket.Conn) {
+	defer c.Close()
+	fetched := map[uint64]bool{}
+	for {
+		_, message, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		var request struct {
+			Action string `json:"action"`
+			Args   struct {
+				ReqID    uint64 `json:"req_id"`
+				User     string `json:"user"`
+				Password string `json:"password"`
+				Sql      string `json:"sql"`
+				ID       uint64 `json:"id"`
+			} `json:"args"`
+		}
+		if err = json.Unmarshal(message, &request); err != nil {
+			return
+		}
+		resp := map[string]interface{}{"code": 0, "action": request.Action, "req_id": request.Args.ReqID}
+		switch request.Action {
+		case "conn":
+			if request.Args.User != "root" || request.Args.Password != "taosdata" {
+				resp["code"], resp["message"] = 0x357, "Authentication failure"
+			}
+		case "query":
+			switch {
+			case strings.HasPrefix(request.Args.Sql, "select"):
+				resp["id"], resp["fields_count"] = 1, 1
+				resp["fields_names"], resp["fields_types"], resp["fields_lengths"] = []string{"v"}, []int{TypeInt}, []int{4}
+			case strings.HasPrefix(request.Args.Sql, "insert"):
+				resp["is_update"], resp["affected_rows"] = true, 2
+			default:
+				resp["code"], resp["message"] = 0x2600, "syntax error"
+			}
+		case "fetch":
+			resp["id"], resp["completed"] = request.Args.ID, fetched[request.Args.ID]
+			if !fetched[request.Args.ID] {
+				resp["rows"], resp["lengths"] = 2, []int{4}
+			}
+			fetched[request.Args.ID] = true
+		case "fetch_block":
+			if err = c.WriteMessage(websocket.BinaryMessage, append(make([]byte, wsBlockPrefixSize), intBlock(1, 2)...)); err != nil {
+				return
+			}
+			continue
+		case "free_result":
+			delete(fetched, request.Args.ID)
+			continue
+		}
+		b, _ := json.Marshal(resp)
+		if err = c.WriteMessage(websocket.TextMessage, b); err != nil {
+			return
+		}
+	}
+}
+
+// closeAll closes the connections from the server side, as taosAdapter or a proxy does with idle ones.
+func (s *wsServer) closeAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range s.conns {
+		_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "idle"), time.Now().Add(time.Second))
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *wsServer) dialCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dials
+}
+
+// intBlock builds a raw block with one INT column.
+func intBlock(values ...int32) []byte {
+	rows := len(values)
+	block := make([]byte, rawBlockHeaderSize+5+4+(rows+7)/8+rows*4)
+	binary.LittleEndian.PutUint32(block[8:], uint32(rows))
+	binary.LittleEndian.PutUint32(block[12:], 1)
+	block[rawBlockHeaderSize] = TypeInt
+	binary.LittleEndian.PutUint32(block[rawBlockHeaderSize+1:], 4)
+	binary.LittleEndian.PutUint32(block[rawBlockHeaderSize+5:], uint32(rows*4))
+	data := block[rawBlockHeaderSize+9+(rows+7)/8:]
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], uint32(v))
+	}
+	return block
+}
+
+func newTestWebSocketConnector(t *testing.T, s *wsServer) *WebSocketConnector {
+	conf := &config.TDengineWebSocket{Address: "ws" + strings.TrimPrefix(s.URL, "http")}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewWebSocketConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return connector
+}
+
+func TestWebSocketConnector(t *testing.T) {
+	s := newWSServer(t)
+	connector := newTestWebSocketConnector(t, s)
+	ctx := context.Background()
+
+	affected, err := connector.Exec(ctx, "insert into t values(now, 1)(now+1s, 2)")
+	if err != nil || affected != 2 {
+		t.Fatalf("Exec returned %d, %v", affected, err)
+	}
+
+	data, err := connector.Query(ctx, "select v from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Head) != 1 || data.Head[0] != "v" || data.Columns[0].Type != TypeInt {
+		t.Fatalf("unexpected columns %v", data.Head)
+	}
+	if len(data.Data) != 2 || data.Data[0][0] != int32(1) || data.Data[1][0] != int32(2) {
+		t.Fatalf("unexpected rows %v", data.Data)
+	}
+
+	rows, err := connector.QueryRows(ctx, "select v from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int32
+	for rows.Next() {
+		var v int32
+		if err = rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		sum += v
+	}
+	if err = rows.Err(); err != nil || sum != 3 {
+		t.Fatalf("QueryRows summed %d, %v", sum, err)
+	}
+	rows.Close()
+
+	_, err = connector.Exec(ctx, "bad sql")
+	var tdengineError *common.TDengineError
+	if !errors.As(err, &tdengineError) || tdengineError.Code != 0x2600 {
+		t.Fatalf("got error %v, want the TDengine error", err)
+	}
+	if s.dialCount() != 1 {
+		t.Fatalf("dialed %d connections, want the idle one reused", s.dialCount())
+	}
+}
+
+func TestWebSocketConnectorAuthFailure(t *testing.T) {
+	s := newWSServer(t)
+	conf := &config.TDengineWebSocket{Address: "ws" + strings.TrimPrefix(s.URL, "http"), Password: "wrong"}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewWebSocketConnector(conf)
+	var tdengineError *common.TDengineError
+	if !errors.As(err, &tdengineError) || tdengineError.Code != 0x357 {
+		t.Fatalf("got error %v, want the authentication failure", err)
+	}
+}
+
+func TestWebSocketConnectorRedial(t *testing.T) {
+	s := newWSServer(t)
+	connector := newTestWebSocketConnector(t, s)
+	s.closeAll()
+	affected, err := connector.Exec(context.Background(), "insert into t values(now, 1)(now+1s, 2)")
+	if err != nil || affected != 2 {
+		t.Fatalf("Exec on a connection closed by the server returned %d, %v", affected, err)
+	}
+	if s.dialCount() != 2 {
+		t.Fatalf("dialed %d connections, want a new one after the idle one was closed", s.dialCount())
+	}
+}
+
+func TestWebSocketConnectorMaxIdleTime(t *testing.T) {
+	s := newWSServer(t)
+	conf := &config.TDengineWebSocket{Address: "ws" + strings.TrimPrefix(s.URL, "http"), MaxIdleTime: time.Nanosecond}
+	if err := conf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewWebSocketConnector(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connector.Close()
+	if _, err = connector.Exec(context.Background(), "insert into t values(now, 1)(now+1s, 2)"); err != nil {
+		t.Fatal(err)
+	}
+	if s.dialCount() != 2 {
+		t.Fatalf("dialed %d connections, want a new one once the idle one is over MaxIdleTime", s.dialCount())
+	}
+}
+
+func TestWebSocketConnectorClose(t *testing.T) {
+	s := newWSServer(t)
+	connector := newTestWebSocketConnector(t, s)
+	rows, err := connector.QueryRows(context.Background(), "select v from t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = connector.Exec(context.Background(), "insert into t values(now, 1)"); err != nil || len(connector.idle) != 1 {
+		t.Fatalf("Exec returned %v with %d idle connections, want one", err, len(connector.idle))
+	}
+	if err = connector.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if len(connector.idle) != 0 {
+		t.Fatalf("%d idle connections left after Close", len(connector.idle))
+	}
+	if _, err = connector.Exec(context.Background(), "insert into t values(now, 1)"); !errors.Is(err, errConnectorClosed) {
+		t.Fatalf("got %v after Close, want errConnectorClosed", err)
+	}
+}
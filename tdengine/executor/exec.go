@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -11,6 +12,8 @@ import (
 	"github.com/taosdata/go-utils/pool"
 	"github.com/taosdata/go-utils/tdengine/common"
 	"github.com/taosdata/go-utils/tdengine/connector"
+	"github.com/taosdata/go-utils/tdengine/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Logger interface {
@@ -24,8 +27,12 @@ type Executor struct {
 	logger     Logger
 }
 
-func NewExecutor(connector connector.TDengineConnector, db string, showSQL bool, logger Logger) *Executor {
-	return &Executor{connector: connector, db: db, showSQL: showSQL, logger: logger}
+// NewExecutor creates an Executor, with showSQL every statement is logged to logger through connector.LogInterceptor.
+func NewExecutor(c connector.TDengineConnector, db string, showSQL bool, logger Logger) *Executor {
+	if showSQL && logger != nil {
+		c = connector.Chain(c, connector.LogInterceptor(logger))
+	}
+	return &Executor{connector: c, db: db, showSQL: showSQL, logger: logger}
 }
 
 type TableInfo struct {
@@ -68,7 +75,7 @@ func (e *Executor) DescribeTable(ctx context.Context, tableName string) (*TableI
 		f := &FieldInfo{
 			Name:   d[FieldIndex].(string),
 			Type:   d[TypeIndex].(string),
-			Length: int(d[LengthIndex].(int32)),
+			Length: toInt(d[LengthIndex]),
 		}
 		if d[NoteIndex] == "TAG" {
 			tags = append(tags, f)
@@ -82,6 +89,33 @@ func (e *Executor) DescribeTable(ctx context.Context, tableName string) (*TableI
 	}, nil
 }
 
+// toInt converts an integer cell of any width, the width depends on the connector and the server version.
+func toInt(v interface{}) int {
+	switch v := v.(type) {
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
 func (e *Executor) CreateSTable(ctx context.Context, tableName string, info *TableInfo) error {
 	fields := info.Fields
 	tags := info.Tags
@@ -109,6 +143,10 @@ func (e *Executor) CreateSTable(ctx context.Context, tableName string, info *Tab
 	return err
 }
 
+// InsertUsingSTable inserts rows into tableName, created from stableName with tags when it does not exist.
+// tags and every value are SQL literals written as is, such as 'beijing',1 and now,1.5.
+//
+// Deprecated: the values are not escaped, use InsertUsingSTableArgs.
 func (e *Executor) InsertUsingSTable(ctx context.Context, tableName string, stableName string, tags string, values []string) error {
 	b := pool.BytesPoolGet()
 	b.WriteString("insert into ")
@@ -129,6 +167,42 @@ func (e *Executor) InsertUsingSTable(ctx context.Context, tableName string, stab
 	return err
 }
 
+// InsertUsingSTableArgs inserts rows into tableName, created from stableName with tags when it does not exist.
+// tags and every row of values are bound to ? placeholders, in the order of the tags and columns of stableName.
+func (e *Executor) InsertUsingSTableArgs(ctx context.Context, tableName string, stableName string, tags []interface{}, values [][]interface{}) error {
+	if len(values) == 0 {
+		return errors.New("no values to insert")
+	}
+	b := pool.BytesPoolGet()
+	b.WriteString("insert into ")
+	b.WriteString(e.WithDBName(tableName))
+	b.WriteString(" using ")
+	b.WriteString(e.WithDBName(stableName))
+	b.WriteString(" tags (")
+	writePlaceholders(b, len(tags))
+	b.WriteString(") values ")
+	args := append([]interface{}{}, tags...)
+	for _, row := range values {
+		b.WriteByte('(')
+		writePlaceholders(b, len(row))
+		b.WriteString(") ")
+		args = append(args, row...)
+	}
+	sql := b.String()
+	pool.BytesPoolPut(b)
+	_, err := e.DoExecArgs(ctx, sql, args...)
+	return err
+}
+
+func writePlaceholders(b *bytes.Buffer, n int) {
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('?')
+	}
+}
+
 func (e *Executor) AddColumn(ctx context.Context, tableType string, tableName string, info *FieldInfo) error {
 	sql := fmt.Sprintf(
 		"alter %s %s add column %s ",
@@ -214,7 +288,18 @@ func (e *Executor) SetTimeLayout(layout string) {
 	e.timeLayout = layout
 }
 
-func (e *Executor) Query(ctx context.Context, request *common.QueryRequest) (*common.QueryResponse, error) {
+func (e *Executor) Query(ctx context.Context, request *common.QueryRequest) (result *common.QueryResponse, err error) {
+	ctx, span := tracing.StartInternal(ctx, "executor.Query", tracing.DBNameKey.String(e.db), attribute.Int("tdengine.tables", len(request.Tables)))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Int("tdengine.results", len(result.Results)))
+		}
+		tracing.End(span, err)
+	}()
+	return e.query(ctx, request)
+}
+
+func (e *Executor) query(ctx context.Context, request *common.QueryRequest) (*common.QueryResponse, error) {
 	var resp common.QueryResponse
 	if len(request.Tables) == 0 {
 		return &resp, nil
@@ -254,7 +339,7 @@ func (e *Executor) Query(ctx context.Context, request *common.QueryRequest) (*co
 		tableName := tn
 		tableInfo := ti
 		wg.Add(1)
-		poolError := pool.GoroutinePool.Submit(func() {
+		poolError := pool.Submit(func() {
 			if err != nil {
 				return
 			}
@@ -276,7 +361,16 @@ func (e *Executor) Query(ctx context.Context, request *common.QueryRequest) (*co
 	return &resp, err
 }
 
-func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *common.Table, request *common.QueryRequest) ([]*common.QueryResult, error) {
+func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *common.Table, request *common.QueryRequest) (result []*common.QueryResult, err error) {
+	ctx, span := tracing.StartInternal(ctx, "executor.queryTask", tracing.DBNameKey.String(e.db), tracing.DBTableKey.String(tableName))
+	defer func() {
+		span.SetAttributes(attribute.Int("tdengine.results", len(result)))
+		tracing.End(span, err)
+	}()
+	return e.doQueryTask(ctx, tableName, tableInfo, request)
+}
+
+func (e *Executor) doQueryTask(ctx context.Context, tableName string, tableInfo *common.Table, request *common.QueryRequest) ([]*common.QueryResult, error) {
 	if len(tableInfo.ColumnList) == 0 {
 		return nil, nil
 	}
@@ -285,7 +379,7 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 		//超级表
 		for _, tagMap := range tableInfo.Tags {
 			//每一组tag进行一次查询
-			sql, err := e.generateQuerySQL(&queryParameter{
+			sql, args, err := e.generateQuerySQL(&queryParameter{
 				tableName:   tableName,
 				aggregation: request.Aggregation,
 				columnList:  tableInfo.ColumnList,
@@ -301,7 +395,7 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 				return nil, err
 			}
 
-			data, err := e.DoQuery(ctx, sql)
+			data, err := e.tracedQueryArgs(ctx, tableName, sql, args)
 			if err != nil {
 				return nil, err
 			}
@@ -321,7 +415,7 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 			}
 		}
 	} else {
-		sql, err := e.generateQuerySQL(&queryParameter{
+		sql, args, err := e.generateQuerySQL(&queryParameter{
 			tableName:   tableName,
 			aggregation: request.Aggregation,
 			columnList:  tableInfo.ColumnList,
@@ -336,7 +430,7 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 		if err != nil {
 			return nil, err
 		}
-		data, err := e.DoQuery(ctx, sql)
+		data, err := e.tracedQueryArgs(ctx, tableName, sql, args)
 		if err != nil {
 			return nil, err
 		}
@@ -344,7 +438,6 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 		if err != nil {
 			return nil, err
 		}
-		q4 := time.Now()
 		for column, resultData := range r {
 			result = append(result, &common.QueryResult{
 				Table:  tableName,
@@ -353,26 +446,41 @@ func (e *Executor) queryTask(ctx context.Context, tableName string, tableInfo *c
 				Values: resultData,
 			})
 		}
-		fmt.Println("q4", time.Since(q4).Microseconds())
 	}
 	return result, nil
 }
 
-func (e *Executor) QueryOneFromSTable(ctx context.Context, sTableName string, whereConditions []string, ts time.Time) (*connector.Data, error) {
+// tracedQueryArgs runs a generated query in its own internal span, the SQL is identified by its hash.
+// The round trip itself is the client span of TracingInterceptor, when it is registered.
+func (e *Executor) tracedQueryArgs(ctx context.Context, tableName string, sql string, args []interface{}) (data *connector.Data, err error) {
+	ctx, span := tracing.StartInternal(ctx, "executor.sql",
+		tracing.DBNameKey.String(e.db),
+		tracing.DBTableKey.String(tableName),
+		tracing.SQLHashKey.String(tracing.SQLHash(sql)),
+	)
+	defer func() {
+		if data != nil {
+			span.SetAttributes(tracing.RowsKey.Int(len(data.Data)))
+		}
+		tracing.End(span, err)
+	}()
+	return e.DoQueryArgs(ctx, sql, args...)
+}
+
+// QueryOneFromSTable queries the row at ts, whereConditions may contain ? placeholders bound to args in order.
+func (e *Executor) QueryOneFromSTable(ctx context.Context, sTableName string, whereConditions []string, ts time.Time, args ...interface{}) (*connector.Data, error) {
 	// select * from stable where ts = ? and tag1 = ? and tag2 = ?
 	b := pool.BytesPoolGet()
 	b.WriteString("select * from ")
 	b.WriteString(e.WithDBName(sTableName))
-	b.WriteString(" where ts = '")
-	b.WriteString(e.formatTime(ts))
-	b.WriteByte('\'')
+	b.WriteString(" where ts = ?")
 	for _, v := range whereConditions {
 		b.WriteString(" and ")
 		b.WriteString(v)
 	}
 	sql := b.String()
 	pool.BytesPoolPut(b)
-	data, err := e.DoQuery(ctx, sql)
+	data, err := e.DoQueryArgs(ctx, sql, append([]interface{}{ts}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -384,32 +492,46 @@ func (e *Executor) QueryOneFromTable(ctx context.Context, tableName string, ts t
 	b := pool.BytesPoolGet()
 	b.WriteString("select * from ")
 	b.WriteString(e.WithDBName(tableName))
-	b.WriteString(" where ts = '")
-	b.WriteString(e.formatTime(ts))
-	b.WriteByte('\'')
+	b.WriteString(" where ts = ?")
 	sql := b.String()
 	pool.BytesPoolPut(b)
-	data, err := e.DoQuery(ctx, sql)
+	data, err := e.DoQueryArgs(ctx, sql, ts)
 	if err != nil {
 		return nil, err
 	}
 	return data, err
 }
 
-func (e *Executor) DoQuery(ctx context.Context, sql string) (*connector.Data, error) {
-	if e.showSQL {
-		e.logger.Info(sql)
+// SchemalessWrite writes InfluxDB line protocol or OpenTSDB lines into the database of the executor,
+// the connector must implement connector.SchemalessWriter.
+func (e *Executor) SchemalessWrite(ctx context.Context, protocol string, lines []string, precision string) error {
+	writer, ok := e.connector.(connector.SchemalessWriter)
+	if !ok {
+		return fmt.Errorf("connector %T does not support schemaless write", e.connector)
 	}
+	return writer.SchemalessWrite(ctx, e.db, protocol, lines, precision)
+}
+
+func (e *Executor) DoQuery(ctx context.Context, sql string) (*connector.Data, error) {
 	return e.connector.Query(ctx, sql)
 }
 
+func (e *Executor) DoQueryRows(ctx context.Context, sql string) (connector.Rows, error) {
+	return e.connector.QueryRows(ctx, sql)
+}
+
 func (e *Executor) DoExec(ctx context.Context, sql string) (int64, error) {
-	if e.showSQL {
-		e.logger.Info(sql)
-	}
 	return e.connector.Exec(ctx, sql)
 }
 
+func (e *Executor) DoQueryArgs(ctx context.Context, sql string, args ...interface{}) (*connector.Data, error) {
+	return e.connector.QueryArgs(ctx, sql, args...)
+}
+
+func (e *Executor) DoExecArgs(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return e.connector.ExecArgs(ctx, sql, args...)
+}
+
 type ShowTablesInfo struct {
 	Name        string
 	CreatedTime time.Time
@@ -560,7 +682,7 @@ func (e *Executor) marshalResult(data *connector.Data) (map[string][]*common.Dat
 		}
 		index := i
 		wg.Add(1)
-		poolError := pool.GoroutinePool.Submit(func() {
+		poolError := pool.Submit(func() {
 			tmp := map[string][]*common.DataItem{}
 			for _, rowData := range d {
 				ts := rowData[tsIndex]
@@ -612,8 +734,10 @@ type queryParameter struct {
 	offset      int
 }
 
-func (e *Executor) generateQuerySQL(parameter *queryParameter) (string, error) {
+// generateQuerySQL returns the sql with ? placeholders for the time range and tag values, and the args to bind.
+func (e *Executor) generateQuerySQL(parameter *queryParameter) (string, []interface{}, error) {
 	b := pool.BytesPoolGet()
+	defer pool.BytesPoolPut(b)
 	//检查聚合参数
 	var columns []string
 	if parameter.aggregation != "" {
@@ -627,60 +751,33 @@ func (e *Executor) generateQuerySQL(parameter *queryParameter) (string, error) {
 	b.WriteString(strings.Join(columns, ","))
 	b.WriteString(" from ")
 	b.WriteString(e.WithDBName(parameter.tableName))
-	b.WriteString(" where ts ")
-	alreadyHaveWhere := false
-	if !parameter.start.IsZero() && !parameter.end.IsZero() {
-		alreadyHaveWhere = true
-		b.WriteString(">= '")
-		b.WriteString(e.formatTime(parameter.start))
-		b.WriteString("' and ts <= '")
-		b.WriteString(e.formatTime(parameter.end))
-		b.WriteString("' ")
-	} else if !parameter.start.IsZero() {
-		alreadyHaveWhere = true
-		b.WriteString(">= '")
-		b.WriteString(e.formatTime(parameter.start))
-		b.WriteString("' ")
-	} else if !parameter.end.IsZero() {
-		alreadyHaveWhere = true
-		b.WriteString("<= '")
-		b.WriteString(e.formatTime(parameter.end))
-		b.WriteString("' ")
-	}
-	if len(parameter.tagMap) != 0 {
-		if alreadyHaveWhere {
-			b.WriteString("and ")
-		} else {
-			b.WriteString("where ")
-			alreadyHaveWhere = true
-		}
+	var conditions []string
+	var args []interface{}
+	if !parameter.start.IsZero() {
+		conditions = append(conditions, "ts >= ?")
+		args = append(args, parameter.start)
+	}
+	if !parameter.end.IsZero() {
+		conditions = append(conditions, "ts <= ?")
+		args = append(args, parameter.end)
 	}
-	var tagList []string
 	for tag, tagValue := range parameter.tagMap {
-		switch tagValue.(type) {
-		case string:
-			tagList = append(tagList, fmt.Sprintf("%s = '%s'", tag, tagValue))
-		default:
-			tagList = append(tagList, fmt.Sprintf("%s = %s", tag, tagValue))
-		}
+		conditions = append(conditions, tag+" = ?")
+		args = append(args, tagValue)
 	}
-	for i := 0; i < len(tagList); i++ {
-		b.WriteString(tagList[i])
-		if i != len(tagList)-1 {
-			b.WriteString(" and ")
-		} else {
-			b.WriteByte(' ')
-		}
+	if len(conditions) != 0 {
+		b.WriteString(" where ")
+		b.WriteString(strings.Join(conditions, " and "))
 	}
 
 	if parameter.interval != "" {
 		if parameter.aggregation == "" {
-			return "", errors.New("aggregation is empty")
+			return "", nil, errors.New("aggregation is empty")
 		}
 		if parameter.fill == "" {
 			parameter.fill = "none"
 		}
-		b.WriteString("interval(")
+		b.WriteString(" interval(")
 		b.WriteString(parameter.interval)
 		b.WriteString(") fill(")
 		b.WriteString(parameter.fill)
@@ -693,9 +790,7 @@ func (e *Executor) generateQuerySQL(parameter *queryParameter) (string, error) {
 	if parameter.offset > 0 {
 		_, _ = fmt.Fprintf(b, " offset %d", parameter.offset)
 	}
-	sql := b.String()
-	pool.BytesPoolPut(b)
-	return sql, nil
+	return b.String(), args, nil
 }
 
 func (e *Executor) WithDBName(source string) string {
@@ -708,7 +803,3 @@ func (e *Executor) generateFieldSql(info *FieldInfo) string {
 	}
 	return fmt.Sprintf("%s %s", info.Name, info.Type)
 }
-
-func (e *Executor) formatTime(t time.Time) string {
-	return t.In(time.Local).Format(time.RFC3339Nano)
-}
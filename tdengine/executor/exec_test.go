@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"github.com/taosdata/go-utils/tdengine/connector"
+	"github.com/taosdata/go-utils/tdengine/connector/connectortest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInsertUsingSTableArgs(t *testing.T) {
+	fake := connectortest.NewFake()
+	const sql = "insert into db.t_1 using db.st tags (?,?) values (?,?) (?,?) "
+	fake.On(sql).ReturnAffected(2)
+	e := NewExecutor(fake, "db", false, nil)
+	err := e.InsertUsingSTableArgs(context.Background(), "t_1", "st", []interface{}{"beijing", 1}, [][]interface{}{{int64(1), 1.5}, {int64(2), 2.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := fake.Statements()
+	want := []interface{}{"beijing", 1, int64(1), 1.5, int64(2), 2.5}
+	if len(statements) != 1 || statements[0].Method != "ExecArgs" || !reflect.DeepEqual(statements[0].Args, want) {
+		t.Fatalf("recorded %v, want ExecArgs with %v", statements, want)
+	}
+	if err = e.InsertUsingSTableArgs(context.Background(), "t_1", "st", nil, nil); err == nil {
+		t.Fatal("inserting no values succeeded")
+	}
+}
+
+func TestInsertUsingSTable(t *testing.T) {
+	fake := connectortest.NewFake()
+	const sql = "insert into db.t_1 using db.st tags ('beijing',1) values (now,1.5) "
+	fake.On(sql).ReturnAffected(1)
+	e := NewExecutor(fake, "db", false, nil)
+	if err := e.InsertUsingSTable(context.Background(), "t_1", "st", "'beijing',1", []string{"now,1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	statements := fake.Statements()
+	if len(statements) != 1 || statements[0].Method != "Exec" || statements[0].SQL != sql {
+		t.Fatalf("recorded %v, want Exec of %s", statements, sql)
+	}
+}
+
+func TestDescribeTable(t *testing.T) {
+	fake := connectortest.NewFake()
+	fake.On("describe db.st").Return(&connector.Data{
+		Head: []string{"Field", "Type", "Length", "Note"},
+		Data: [][]interface{}{
+			{"ts", "TIMESTAMP", int32(8), ""},
+			{"v", "DOUBLE", int32(8), ""},
+			{"location", "NCHAR", int32(64), "TAG"},
+		},
+	})
+	e := NewExecutor(fake, "db", false, nil)
+	info, err := e.DescribeTable(context.Background(), "st")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Fields) != 2 || len(info.Tags) != 1 || info.Tags[0].Name != "location" || info.Tags[0].Length != 64 {
+		t.Fatalf("got fields %v and tags %v", info.Fields, info.Tags)
+	}
+}
+
+func TestCreateSTable(t *testing.T) {
+	fake := connectortest.NewFake()
+	fake.OnRegexp("^create stable ")
+	e := NewExecutor(fake, "db", false, nil)
+	err := e.CreateSTable(context.Background(), "st", &TableInfo{
+		Fields: []*FieldInfo{{Name: "v", Type: "DOUBLE"}},
+		Tags:   []*FieldInfo{{Name: "location", Type: common.NCHARType, Length: 64}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertExecuted(t, "create stable if not exists db.st (ts timestamp,v DOUBLE) tags (location "+common.NCHARType+"(64))")
+}
+
+func TestGetPrecision(t *testing.T) {
+	fake := connectortest.NewFake()
+	fake.On("show databases").Return(&connector.Data{
+		Head: []string{"name", "precision"},
+		Data: [][]interface{}{{"log", "ms"}, {"db", "us"}},
+	})
+	e := NewExecutor(fake, "db", false, nil)
+	precision, err := e.GetPrecision(context.Background())
+	if err != nil || precision != "us" {
+		t.Fatalf("got precision %s, %v, want us", precision, err)
+	}
+}
+
+func TestExecutorErrors(t *testing.T) {
+	fake := connectortest.NewFake()
+	fake.On("show db.stables").ReturnTDengineError(0x0388, "Database not exist")
+	e := NewExecutor(fake, "db", false, nil)
+	_, err := e.GetAllStableNames(context.Background())
+	var tdengineError *common.TDengineError
+	if !errors.As(err, &tdengineError) || tdengineError.Code != 0x0388 {
+		t.Fatalf("got error %v, want the TDengine error", err)
+	}
+}
+
+func TestExecutorSchemalessWrite(t *testing.T) {
+	fake := connectortest.NewFake()
+	fake.On(common.InfluxDBLineProtocol)
+	e := NewExecutor(fake, "db", false, nil)
+	if err := e.SchemalessWrite(context.Background(), common.InfluxDBLineProtocol, []string{"m v=1i"}, "ms"); err != nil {
+		t.Fatal(err)
+	}
+	fake.AssertExpectationsMet(t)
+}
+
+func TestTracedQueryArgs(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(provider)
+
+	fake := connectortest.NewFake()
+	fake.On("select * from db.t where ts >= ?").Return(&connector.Data{Head: []string{"ts"}, Data: [][]interface{}{{int64(1)}}})
+	e := NewExecutor(connector.Chain(fake, connector.TracingInterceptor()), "db", false, nil)
+	if _, err := e.tracedQueryArgs(context.Background(), "t", "select * from db.t where ts >= ?", []interface{}{int64(0)}); err != nil {
+		t.Fatal(err)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	call, executor := spans[0], spans[1]
+	if executor.Name() != "executor.sql" || executor.SpanKind() != trace.SpanKindInternal {
+		t.Fatalf("got executor span %s of kind %s", executor.Name(), executor.SpanKind())
+	}
+	if call.Name() != "tdengine.QueryArgs" || call.SpanKind() != trace.SpanKindClient || call.Parent().SpanID() != executor.SpanContext().SpanID() {
+		t.Fatalf("got call span %s of kind %s with parent %v", call.Name(), call.SpanKind(), call.Parent())
+	}
+}
@@ -0,0 +1,77 @@
+// Package tracing holds the OpenTelemetry helpers shared by the connectors, the executor and the web package.
+// Spans go to the global tracer provider, nothing is exported until the application sets one with otel.SetTracerProvider.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/taosdata/go-utils/tdengine/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const InstrumentationName = "github.com/taosdata/go-utils/tdengine"
+
+const (
+	DBSystemKey    = attribute.Key("db.system")
+	DBNameKey      = attribute.Key("db.name")
+	DBOperationKey = attribute.Key("db.operation")
+	DBTableKey     = attribute.Key("db.sql.table")
+	SQLHashKey     = attribute.Key("tdengine.sql.hash")
+	RowsKey        = attribute.Key("tdengine.rows")
+	AffectedKey    = attribute.Key("tdengine.affected")
+	ErrorCodeKey   = attribute.Key("tdengine.error.code")
+	ConnectorKey   = attribute.Key("tdengine.connector")
+	// QueueTimeKey is the time a call waited for the client side limits, in microseconds.
+	QueueTimeKey = attribute.Key("tdengine.queue_time")
+)
+
+// DBSystem is the db.system attribute of every span.
+var DBSystem = DBSystemKey.String("tdengine")
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start starts a client span, for a call to TDengine, as a child of the span in ctx.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindClient, attributes)
+}
+
+// StartInternal starts an internal span, for work grouping several calls such as an executor query, as a child of the span in ctx.
+func StartInternal(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindInternal, attributes)
+}
+
+func start(ctx context.Context, name string, kind trace.SpanKind, attributes []attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(append(attributes, DBSystem)...))
+}
+
+// SQLHash returns a short stable hash of sql, it identifies a statement without recording its values.
+func SQLHash(sql string) string {
+	h := fnv.New64a()
+	h.Write([]byte(sql))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// End records err on span, with its TDengine or HTTP status code when there is one, and ends span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		var tdengineError *common.TDengineError
+		var statusError *common.HTTPStatusError
+		switch {
+		case errors.As(err, &tdengineError):
+			span.SetAttributes(ErrorCodeKey.Int(tdengineError.Code))
+		case errors.As(err, &statusError):
+			span.SetAttributes(ErrorCodeKey.Int(statusError.StatusCode))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -2,56 +2,33 @@ package web
 
 import (
 	"github.com/gin-contrib/cors"
-	"os"
-	"strconv"
-	"strings"
+	"github.com/taosdata/go-utils/config"
 )
 
+// defaultAllowHeaders are always allowed, AllowHeaders only adds to them.
+var defaultAllowHeaders = []string{"Authorization", "AccessKey", "X-AccessKey", "UserToken", "X-Language", "X-WebAppID", "X-App", "X-Action"}
+
+// CorsConfig is filled by Init, or by the go-utils config package with the CORS environment prefix.
+// Both ways AllowHeaders (CORS_ALLOW_HEADERS) is added to the default allowed headers.
 type CorsConfig struct {
 	AllowAllOrigins  bool
 	AllowOrigins     []string
 	AllowHeaders     []string
 	ExposeHeaders    []string
-	AllowCredentials bool
-	AllowWebSockets  bool
+	AllowCredentials bool `default:"true"`
+	AllowWebSockets  bool `config:"allow_websockets" default:"true"`
 }
 
-func (conf *CorsConfig) Init() {
-	if !conf.AllowAllOrigins {
-		if val := os.Getenv("CORS_ALLOW_ALL_ORIGINS"); val != "" {
-			conf.AllowAllOrigins, _ = strconv.ParseBool(val)
-		}
-	}
-
-	if len(conf.AllowOrigins) == 0 {
-		if val := os.Getenv("CORS_ALLOW_ORIGINS"); val != "" {
-			conf.AllowOrigins = strings.Split(val, ",")
-		}
-	}
-
-	allowHeaders := []string{"Authorization", "AccessKey", "X-AccessKey", "UserToken", "X-Language", "X-WebAppID", "X-App", "X-Action"}
-	if len(conf.AllowHeaders) == 0 {
-		if val := os.Getenv("CORS_ALLOW_HEADERS"); val != "" {
-			allowHeaders = append(allowHeaders, strings.Split(val, ",")...)
-		}
-	}
-	conf.AllowHeaders = allowHeaders
-
-	if !conf.AllowCredentials {
-		if val := os.Getenv("CORS_ALLOW_CREDENTIALS"); val != "" {
-			conf.AllowCredentials, _ = strconv.ParseBool(val)
-		} else {
-			conf.AllowCredentials = true
-		}
-	}
+// Init fills the fields still zero from the CORS_ environment variables and the default tags,
+// the returned config.Errors lists every invalid variable.
+func (conf *CorsConfig) Init() error {
+	return config.Load(conf, &config.Options{EnvPrefix: "CORS", KeepSet: true})
+}
 
-	if !conf.AllowWebSockets {
-		if val := os.Getenv("CORS_ALLOW_WEBSOCKETS"); val != "" {
-			conf.AllowWebSockets, _ = strconv.ParseBool(val)
-		} else {
-			conf.AllowWebSockets = true
-		}
-	}
+// Validate returns the error cors.New would panic with.
+func (conf *CorsConfig) Validate() error {
+	corsConfig := conf.GetConfig()
+	return corsConfig.Validate()
 }
 
 func (conf *CorsConfig) GetConfig() cors.Config {
@@ -67,6 +44,7 @@ func (conf *CorsConfig) GetConfig() cors.Config {
 			corsConfig.AllowOrigins = conf.AllowOrigins
 		}
 	}
+	corsConfig.AddAllowHeaders(defaultAllowHeaders...)
 	if len(conf.AllowHeaders) > 0 {
 		corsConfig.AddAllowHeaders(conf.AllowHeaders...)
 	}
@@ -0,0 +1,28 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/taosdata/go-utils/config"
+)
+
+func TestCorsAllowHeaders(t *testing.T) {
+	t.Setenv("CORS_ALLOW_HEADERS", "X-Custom")
+	initConf := &CorsConfig{}
+	if err := initConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	loadConf := &CorsConfig{}
+	if err := config.Load(loadConf, &config.Options{EnvPrefix: "CORS"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, conf := range map[string]*CorsConfig{"Init": initConf, "Load": loadConf} {
+		allowed := map[string]bool{}
+		for _, header := range conf.GetConfig().AllowHeaders {
+			allowed[header] = true
+		}
+		if !allowed["X-Custom"] || !allowed["Authorization"] || !allowed["X-Action"] {
+			t.Fatalf("%s allows %v, want the default and the configured headers", name, conf.GetConfig().AllowHeaders)
+		}
+	}
+}
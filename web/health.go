@@ -0,0 +1,64 @@
+package web
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/taosdata/go-utils/tdengine/common"
+	"net/http"
+	"time"
+)
+
+// HealthChecker is implemented by the TDengine connectors, see connector.HealthChecker.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	Health(ctx context.Context) (*common.Health, error)
+}
+
+// HealthController serves /healthz and /readyz for Kubernetes probes, both fail with 503 when TDengine is unreachable.
+// /healthz only pings, /readyz also reports the server version, the round trip and the auth status.
+type HealthController struct {
+	checker HealthChecker
+	timeout time.Duration
+}
+
+// NewHealthController creates a controller checking checker, every check is bounded by timeout, 0 means 5 seconds.
+func NewHealthController(checker HealthChecker, timeout time.Duration) *HealthController {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HealthController{checker: checker, timeout: timeout}
+}
+
+func (h *HealthController) Init(router gin.IRouter) {
+	router.GET("/healthz", h.healthz)
+	router.GET("/readyz", h.readyz)
+}
+
+func (h *HealthController) healthz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	if err := h.checker.Ping(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthController) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+	health, err := h.checker.Health(ctx)
+	result := gin.H{"status": "ok"}
+	if health != nil {
+		result["server_version"] = health.ServerVersion
+		result["round_trip"] = health.RoundTrip.String()
+		result["authenticated"] = health.Authenticated
+	}
+	if err != nil {
+		result["status"] = "unavailable"
+		result["error"] = err.Error()
+		c.JSON(http.StatusServiceUnavailable, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
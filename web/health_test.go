@@ -0,0 +1,76 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/taosdata/go-utils/tdengine/common"
+)
+
+type stubChecker struct {
+	err      error
+	deadline bool
+}
+
+func (s *stubChecker) Ping(ctx context.Context) error {
+	_, s.deadline = ctx.Deadline()
+	return s.err
+}
+
+func (s *stubChecker) Health(ctx context.Context) (*common.Health, error) {
+	_, s.deadline = ctx.Deadline()
+	return &common.Health{ServerVersion: "3.0.0.0", RoundTrip: 2 * time.Millisecond, Authenticated: s.err == nil}, s.err
+}
+
+func TestHealthController(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	tests := []struct {
+		name   string
+		err    error
+		path   string
+		status int
+		want   map[string]interface{}
+	}{
+		{name: "healthy healthz", path: "/healthz", status: http.StatusOK, want: map[string]interface{}{"status": "ok"}},
+		{name: "unhealthy healthz", err: errors.New("connection refused"), path: "/healthz", status: http.StatusServiceUnavailable,
+			want: map[string]interface{}{"status": "unavailable", "error": "connection refused"}},
+		{name: "healthy readyz", path: "/readyz", status: http.StatusOK,
+			want: map[string]interface{}{"status": "ok", "server_version": "3.0.0.0", "round_trip": "2ms", "authenticated": true}},
+		{name: "unhealthy readyz", err: errors.New("Authentication failure"), path: "/readyz", status: http.StatusServiceUnavailable,
+			want: map[string]interface{}{"status": "unavailable", "error": "Authentication failure", "server_version": "3.0.0.0",
+				"round_trip": "2ms", "authenticated": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := &stubChecker{err: tt.err}
+			router := gin.New()
+			NewHealthController(checker, 0).Init(router)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.status {
+				t.Fatalf("got status %d, want %d", recorder.Code, tt.status)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+			if !checker.deadline {
+				t.Fatal("the check ran without a timeout")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
 )
 
@@ -15,6 +16,7 @@ func CreateRouter(debug bool, corsConf *CorsConfig, enableGzip bool) *gin.Engine
 		gin.SetMode(gin.ReleaseMode)
 	}
 	router := gin.Default()
+	router.Use(Tracing())
 
 	if debug {
 		pprof.Register(router)
@@ -23,9 +25,16 @@ func CreateRouter(debug bool, corsConf *CorsConfig, enableGzip bool) *gin.Engine
 		router.Use(gzip.Gzip(gzip.DefaultCompression))
 	}
 	router.Use(cors.New(corsConf.GetConfig()))
+	RegisterMetrics(router)
 	return router
 }
 
+// RegisterMetrics serves the default Prometheus registry on GET /metrics,
+// CreateRouter calls it, it is only needed for routers built without CreateRouter.
+func RegisterMetrics(router gin.IRoutes) {
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
+
 type BaseController struct {
 }
 
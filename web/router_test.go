@@ -0,0 +1,20 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateRouterMetrics(t *testing.T) {
+	corsConf := &CorsConfig{}
+	if err := corsConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	CreateRouter(false, corsConf, false).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "go_goroutines") {
+		t.Fatalf("got %d %s, want the default registry", recorder.Code, recorder.Body.String())
+	}
+}
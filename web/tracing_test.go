@@ -0,0 +1,65 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCreateRouterTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+
+	corsConf := &CorsConfig{}
+	if err := corsConf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	router := CreateRouter(false, corsConf, false)
+	var handlerSpan trace.SpanContext
+	router.GET("/tables/:name", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/tables/t1", nil)
+	req.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /tables/:name" || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("got span %s of kind %s", span.Name(), span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != "0102030405060708090a0b0c0d0e0f10" || span.Parent().SpanID().String() != "0102030405060708" {
+		t.Fatalf("span does not continue the propagated trace, parent %v", span.Parent())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("the handler context does not carry the request span")
+	}
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	if attributes["http.route"].AsString() != "/tables/:name" || attributes["http.status_code"].AsInt64() != http.StatusInternalServerError {
+		t.Fatalf("got attributes %v", span.Attributes())
+	}
+	if span.Status().Code.String() != "Error" {
+		t.Fatalf("got status %v, want Error", span.Status())
+	}
+}